@@ -42,6 +42,22 @@ var (
 	Port                = getEnv("PORT", "6060")
 	AutoLeave           = getEnvBool("AUTO_LEAVE", false)
 	EnableVideoPlayback = getEnvBool("ENABLE_VPLAY", true)
+	PersistQueues       = getEnvBool("PERSIST_QUEUES", true)
+	QueueLimit          = getEnvInt64("QUEUE_LIMIT", 10)
+	VoteSkipPercent     = getEnvInt64("VOTE_SKIP_PERCENT", 50)
+	QueueStore          = strings.ToLower(getEnv("QUEUE_STORE", "memory"))
+	RedisUrl            = os.Getenv("REDIS_URL")
+	DefaultQuality      = strings.ToLower(getEnv("DEFAULT_QUALITY", "standard"))
+	RadioCatalog        = os.Getenv("RADIO_CATALOG")
+	IngestProtocol      = strings.ToLower(getEnv("INGEST_PROTOCOL", "srt"))
+	IngestHost          = os.Getenv("INGEST_HOST")
+	IngestPorts         = parsePortRange(os.Getenv("INGEST_PORTS"))
+	RecordMaxDuration   = getEnvInt64("RECORD_MAX_DURATION", 3*3600)
+	RecordMaxSize       = getEnvInt64("RECORD_MAX_SIZE", 200*1024*1024)
+	RecordTotalSize     = getEnvInt64("RECORD_TOTAL_SIZE", 1024*1024*1024)
+	RecordUpload        = strings.ToLower(getEnv("RECORD_UPLOAD", "chat"))
+	SoundboardMaxClips  = getEnvInt64("SOUNDBOARD_MAX_CLIPS", 20)
+	SoundboardMaxLength = getEnvInt64("SOUNDBOARD_MAX_LENGTH", 15)
 
 	DEVS        []int64
 	CookiesPath []string
@@ -155,6 +171,31 @@ func processCookieURLs(urls string) []string {
 	return result
 }
 
+// parsePortRange parses a port or an inclusive range of ports such as "1935-1940"
+func parsePortRange(value string) []int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	first, last, isRange := strings.Cut(value, "-")
+	start, err := strconv.Atoi(strings.TrimSpace(first))
+	end := start
+	if err == nil && isRange {
+		end, err = strconv.Atoi(strings.TrimSpace(last))
+	}
+	if err != nil || start < 1 || end > 65535 || start > end {
+		slog.Info("Invalid INGEST_PORTS, live ingest is disabled", "Ports", value)
+		return nil
+	}
+
+	ports := make([]int, 0, end-start+1)
+	for port := start; port <= end; port++ {
+		ports = append(ports, port)
+	}
+	return ports
+}
+
 // containsInt checks if a slice contains a specific int64 value
 func containsInt(slice []int64, val int64) bool {
 	for _, item := range slice {
@@ -199,6 +240,21 @@ func validate() error {
 		slog.Info("Invalid DEFAULT_SERVICE, defaulting to 'youtube'", "Service", DefaultService)
 	}
 
+	if !isValidQuality(DefaultQuality) {
+		DefaultQuality = "standard"
+		slog.Info("Invalid DEFAULT_QUALITY, defaulting to 'standard'", "Quality", DefaultQuality)
+	}
+
+	if IngestProtocol != "rtmp" && IngestProtocol != "srt" {
+		IngestProtocol = "srt"
+		slog.Info("Invalid INGEST_PROTOCOL, defaulting to 'srt'", "Protocol", IngestProtocol)
+	}
+
+	if RecordUpload != "chat" && RecordUpload != "logger" {
+		RecordUpload = "chat"
+		slog.Info("Invalid RECORD_UPLOAD, defaulting to 'chat'", "Upload", RecordUpload)
+	}
+
 	return nil
 }
 
@@ -210,3 +266,12 @@ func isValidService(service string) bool {
 	}
 	return validServices[strings.ToLower(service)]
 }
+
+// isValidQuality checks if the quality profile name is valid
+func isValidQuality(quality string) bool {
+	switch quality {
+	case "low", "standard", "high":
+		return true
+	}
+	return false
+}
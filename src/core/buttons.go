@@ -12,6 +12,7 @@ import (
 	"ashokshau/tgmusic/config"
 	"ashokshau/tgmusic/src/utils"
 	"fmt"
+	"hash/fnv"
 
 	"github.com/AshokShau/gotdbot"
 )
@@ -75,25 +76,77 @@ func SupportBtn() *gotdbot.ReplyMarkupInlineKeyboard {
 	}
 }
 
-func SettingsKeyboard(playMode, adminMode string, cmdDelete bool, language string) *gotdbot.ReplyMarkupInlineKeyboard {
+// ChatSettings holds the chat settings shown on the /settings keyboard.
+type ChatSettings struct {
+	PlayMode           string
+	AdminMode          string
+	CmdDelete          bool
+	Language           string
+	QueueLimit         int
+	UserQueueLimit     int
+	QueueDurationLimit int
+	FairQueue          bool
+	DuplicateMode      string
+	Volume             int
+	Normalize          bool
+	Quality            string
+}
+
+func SettingsKeyboard(s ChatSettings) *gotdbot.ReplyMarkupInlineKeyboard {
 	playText := "Everyone"
-	if playMode == utils.Admins {
+	if s.PlayMode == utils.Admins {
 		playText = "Admins"
 	}
 
 	deleteText := "False"
-	if cmdDelete {
+	if s.CmdDelete {
 		deleteText = "True"
 	}
 
 	adminText := "Everyone"
-	if adminMode == utils.Admins {
+	if s.AdminMode == utils.Admins {
 		adminText = "Admins"
 	}
 
 	langText := "English"
-	if language != "en" && language != "" {
-		langText = language
+	if s.Language != "en" && s.Language != "" {
+		langText = s.Language
+	}
+
+	userLimitText := "Unlimited"
+	if s.UserQueueLimit > 0 {
+		userLimitText = fmt.Sprintf("%d tracks", s.UserQueueLimit)
+	}
+
+	durationLimitText := "Unlimited"
+	if s.QueueDurationLimit > 0 {
+		durationLimitText = fmt.Sprintf("%d min", s.QueueDurationLimit/60)
+	}
+
+	fairText := "Off"
+	if s.FairQueue {
+		fairText = "On"
+	}
+
+	normalizeText := "Off"
+	if s.Normalize {
+		normalizeText = "On"
+	}
+
+	qualityText := "Standard"
+	switch s.Quality {
+	case utils.QualityLow:
+		qualityText = "Low"
+	case utils.QualityHigh:
+		qualityText = "High"
+	}
+
+	duplicateText := "Reject"
+	switch s.DuplicateMode {
+	case utils.DuplicateWarn:
+		duplicateText = "Warn"
+	case utils.DuplicateAllow:
+		duplicateText = "Allow"
 	}
 
 	return &gotdbot.ReplyMarkupInlineKeyboard{
@@ -114,6 +167,38 @@ func SettingsKeyboard(playMode, adminMode string, cmdDelete bool, language strin
 				cb("Language ➜", "settings_main", gotdbot.ButtonStyleDefault{}),
 				cb(langText, "settings_lang", gotdbot.ButtonStyleDefault{}),
 			},
+			{
+				cb("Queue Limit ➜", "settings_main", gotdbot.ButtonStyleDefault{}),
+				cb(fmt.Sprintf("%d tracks", s.QueueLimit), "settings_qlimit", gotdbot.ButtonStyleDefault{}),
+			},
+			{
+				cb("Per User ➜", "settings_main", gotdbot.ButtonStyleDefault{}),
+				cb(userLimitText, "settings_ulimit", gotdbot.ButtonStyleDefault{}),
+			},
+			{
+				cb("Queue Duration ➜", "settings_main", gotdbot.ButtonStyleDefault{}),
+				cb(durationLimitText, "settings_dlimit", gotdbot.ButtonStyleDefault{}),
+			},
+			{
+				cb("Fair Queue ➜", "settings_main", gotdbot.ButtonStyleDefault{}),
+				cb(fairText, "settings_fair", gotdbot.ButtonStyleDefault{}),
+			},
+			{
+				cb("Duplicates ➜", "settings_main", gotdbot.ButtonStyleDefault{}),
+				cb(duplicateText, "settings_dup", gotdbot.ButtonStyleDefault{}),
+			},
+			{
+				cb("Volume ➜", "settings_main", gotdbot.ButtonStyleDefault{}),
+				cb(fmt.Sprintf("%d%%", s.Volume), "settings_volume", gotdbot.ButtonStyleDefault{}),
+			},
+			{
+				cb("Normalize ➜", "settings_main", gotdbot.ButtonStyleDefault{}),
+				cb(normalizeText, "settings_norm", gotdbot.ButtonStyleDefault{}),
+			},
+			{
+				cb("Quality ➜", "settings_main", gotdbot.ButtonStyleDefault{}),
+				cb(qualityText, "settings_quality", gotdbot.ButtonStyleDefault{}),
+			},
 			{CloseBtn},
 		},
 	}
@@ -138,7 +223,9 @@ func BackHelpMenuKeyboard() *gotdbot.ReplyMarkupInlineKeyboard {
 	}
 }
 
-func ControlButtons(mode string) *gotdbot.ReplyMarkupInlineKeyboard {
+// ControlButtons returns the playback controls for the given player state. volume is the
+// chat's volume level in percent, shown between the volume buttons.
+func ControlButtons(mode string, volume int) *gotdbot.ReplyMarkupInlineKeyboard {
 	skipBtn := cb("‣‣I", "play_skip", gotdbot.ButtonStyleDefault{})
 	stopBtn := cb("▢", "play_stop", gotdbot.ButtonStyleDefault{})
 	pauseBtn := cb("II", "play_pause", gotdbot.ButtonStyleDefault{})
@@ -146,6 +233,12 @@ func ControlButtons(mode string) *gotdbot.ReplyMarkupInlineKeyboard {
 	muteBtn := cb("🔇", "play_mute", gotdbot.ButtonStyleDefault{})
 	unmuteBtn := cb("🔊", "play_unmute", gotdbot.ButtonStyleDefault{})
 	addToPlaylistBtn := cb("➕", "play_add_to_list", gotdbot.ButtonStylePrimary{})
+	voteSkipBtn := cb("🗳 Vote Skip", "vote_skip", gotdbot.ButtonStyleDefault{})
+	volumeRow := []gotdbot.InlineKeyboardButton{
+		cb("−", "play_voldown", gotdbot.ButtonStyleDefault{}),
+		cb(fmt.Sprintf("Vol %d%%", volume), "play_volume", gotdbot.ButtonStyleDefault{}),
+		cb("+", "play_volup", gotdbot.ButtonStyleDefault{}),
+	}
 
 	switch mode {
 
@@ -153,7 +246,8 @@ func ControlButtons(mode string) *gotdbot.ReplyMarkupInlineKeyboard {
 		return &gotdbot.ReplyMarkupInlineKeyboard{
 			Rows: [][]gotdbot.InlineKeyboardButton{
 				{skipBtn, stopBtn, pauseBtn},
-				{addToPlaylistBtn, CloseBtn},
+				volumeRow,
+				{addToPlaylistBtn, voteSkipBtn, CloseBtn},
 			},
 		}
 
@@ -161,6 +255,7 @@ func ControlButtons(mode string) *gotdbot.ReplyMarkupInlineKeyboard {
 		return &gotdbot.ReplyMarkupInlineKeyboard{
 			Rows: [][]gotdbot.InlineKeyboardButton{
 				{skipBtn, stopBtn, resumeBtn},
+				volumeRow,
 				{CloseBtn},
 			},
 		}
@@ -169,7 +264,8 @@ func ControlButtons(mode string) *gotdbot.ReplyMarkupInlineKeyboard {
 		return &gotdbot.ReplyMarkupInlineKeyboard{
 			Rows: [][]gotdbot.InlineKeyboardButton{
 				{skipBtn, stopBtn, pauseBtn},
-				{CloseBtn},
+				volumeRow,
+				{voteSkipBtn, CloseBtn},
 			},
 		}
 
@@ -177,7 +273,8 @@ func ControlButtons(mode string) *gotdbot.ReplyMarkupInlineKeyboard {
 		return &gotdbot.ReplyMarkupInlineKeyboard{
 			Rows: [][]gotdbot.InlineKeyboardButton{
 				{skipBtn, stopBtn, unmuteBtn},
-				{CloseBtn},
+				volumeRow,
+				{voteSkipBtn, CloseBtn},
 			},
 		}
 
@@ -185,7 +282,8 @@ func ControlButtons(mode string) *gotdbot.ReplyMarkupInlineKeyboard {
 		return &gotdbot.ReplyMarkupInlineKeyboard{
 			Rows: [][]gotdbot.InlineKeyboardButton{
 				{skipBtn, stopBtn, muteBtn},
-				{CloseBtn},
+				volumeRow,
+				{voteSkipBtn, CloseBtn},
 			},
 		}
 
@@ -220,6 +318,44 @@ func PlayNowButton(trackID string) gotdbot.InlineKeyboardButton {
 	return cb("Play Now", fmt.Sprintf("play_now_%s", trackID), gotdbot.ButtonStyleDanger{})
 }
 
+// QueueTrackKey returns a short key identifying a track in queue browser buttons, so a button
+// pressed on an outdated queue page can tell that its position now holds another track.
+func QueueTrackKey(trackID string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(trackID))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// QueueKeyboard builds the queue browser keyboard for the tracks shown on a page, numbered
+// from start.
+func QueueKeyboard(page, pages, start int, tracks []*utils.CachedTrack) *gotdbot.ReplyMarkupInlineKeyboard {
+	var rows [][]gotdbot.InlineKeyboardButton
+	for n, track := range tracks {
+		i, key := start+n, QueueTrackKey(track.TrackID)
+		rows = append(rows, []gotdbot.InlineKeyboardButton{
+			cb(fmt.Sprintf("▷ %d", i), fmt.Sprintf("queue_play_%d_%s_%d", i, key, page), gotdbot.ButtonStyleDefault{}),
+			cb(fmt.Sprintf("↑ %d", i), fmt.Sprintf("queue_up_%d_%s_%d", i, key, page), gotdbot.ButtonStyleDefault{}),
+			cb(fmt.Sprintf("✕ %d", i), fmt.Sprintf("queue_rm_%d_%s_%d", i, key, page), gotdbot.ButtonStyleDefault{}),
+		})
+	}
+
+	if pages > 1 {
+		prev := page - 1
+		if prev < 0 {
+			prev = pages - 1
+		}
+		next := (page + 1) % pages
+		rows = append(rows, []gotdbot.InlineKeyboardButton{
+			cb("«", fmt.Sprintf("queue_page_%d", prev), gotdbot.ButtonStyleDefault{}),
+			cb(fmt.Sprintf("%d/%d", page+1, pages), fmt.Sprintf("queue_page_%d", page), gotdbot.ButtonStyleDefault{}),
+			cb("»", fmt.Sprintf("queue_page_%d", next), gotdbot.ButtonStyleDefault{}),
+		})
+	}
+
+	rows = append(rows, []gotdbot.InlineKeyboardButton{CloseBtn})
+	return &gotdbot.ReplyMarkupInlineKeyboard{Rows: rows}
+}
+
 func QueueMarkup(trackID string) *gotdbot.ReplyMarkupInlineKeyboard {
 	return &gotdbot.ReplyMarkupInlineKeyboard{
 		Rows: [][]gotdbot.InlineKeyboardButton{
@@ -227,3 +363,16 @@ func QueueMarkup(trackID string) *gotdbot.ReplyMarkupInlineKeyboard {
 		},
 	}
 }
+
+// SoundboardKeyboard builds a keyboard with a button that plays each of the named soundboard clips.
+func SoundboardKeyboard(names []string) *gotdbot.ReplyMarkupInlineKeyboard {
+	var rows [][]gotdbot.InlineKeyboardButton
+	for i, name := range names {
+		if i%3 == 0 {
+			rows = append(rows, nil)
+		}
+		rows[len(rows)-1] = append(rows[len(rows)-1], cb(name, "sb_"+name, gotdbot.ButtonStyleDefault{}))
+	}
+	rows = append(rows, []gotdbot.InlineKeyboardButton{CloseBtn})
+	return &gotdbot.ReplyMarkupInlineKeyboard{Rows: rows}
+}
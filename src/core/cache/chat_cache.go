@@ -10,26 +10,65 @@ package cache
 
 import (
 	"ashokshau/tgmusic/src/utils"
+	"errors"
+	"math/rand/v2"
+	"slices"
 	"sync"
+	"time"
+)
+
+// RepeatMode controls what happens to a track once it has finished playing.
+type RepeatMode string
+
+const (
+	RepeatOff   RepeatMode = "off"
+	RepeatTrack RepeatMode = "track"
+	RepeatQueue RepeatMode = "queue"
 )
 
 // ChatData holds the state of a chat's music queue.
 type ChatData struct {
 	Queue            []*utils.CachedTrack
 	Autoplay         bool
+	Repeat           RepeatMode
+	Fair             bool
 	LastYouTubeTrack *utils.CachedTrack
 }
 
+// ChatSnapshot is a point-in-time copy of a chat's queue state that can be persisted
+// and later handed back to Restore.
+type ChatSnapshot struct {
+	ChatID           int64                `json:"chat_id" bson:"_id"`
+	Queue            []*utils.CachedTrack `json:"queue" bson:"queue"`
+	Autoplay         bool                 `json:"autoplay" bson:"autoplay"`
+	Repeat           RepeatMode           `json:"repeat,omitempty" bson:"repeat,omitempty"`
+	Fair             bool                 `json:"fair,omitempty" bson:"fair,omitempty"`
+	LastYouTubeTrack *utils.CachedTrack   `json:"last_youtube_track,omitempty" bson:"last_youtube_track,omitempty"`
+	History          []*utils.CachedTrack `json:"history,omitempty" bson:"history,omitempty"`
+	Position         int                  `json:"position" bson:"position"`
+	UpdatedAt        time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+// historySize is the number of recently played tracks kept per chat.
+const historySize = 20
+
 // ChatCacher is a thread-safe cache that manages music queues for multiple chats.
 type ChatCacher struct {
 	mu        sync.RWMutex
 	chatCache map[int64]*ChatData
+	// history holds recently played tracks, oldest first. It is kept separately from
+	// ChatData so it survives ClearChat when playback stops.
+	history map[int64][]*utils.CachedTrack
+
+	hooksMu sync.RWMutex
+	hooks   []func(chatID int64)
 }
 
 // newChatCacher initializes and returns a new ChatCacher.
 func newChatCacher() *ChatCacher {
 	return &ChatCacher{
 		chatCache: make(map[int64]*ChatData),
+		history:   make(map[int64][]*utils.CachedTrack),
 	}
 }
 
@@ -39,34 +78,152 @@ func (c *ChatCacher) getOrCreate(chatID int64) *ChatData {
 	data, ok := c.chatCache[chatID]
 	if !ok {
 		data = &ChatData{}
+		if LoadDefaults != nil {
+			defaults := LoadDefaults(chatID)
+			data.Fair = defaults.Fair
+			data.Repeat = defaults.Repeat
+		}
 		c.chatCache[chatID] = data
 	}
 	return data
 }
 
+// OnChange registers a hook that is called after a chat's queue state is modified.
+// Hooks run outside the cache lock, so they may call back into the ChatCacher.
+func (c *ChatCacher) OnChange(hook func(chatID int64)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.hooks = append(c.hooks, hook)
+}
+
+// notify calls the registered change hooks for a chat.
+func (c *ChatCacher) notify(chatID int64) {
+	c.hooksMu.RLock()
+	hooks := c.hooks
+	c.hooksMu.RUnlock()
+
+	for _, hook := range hooks {
+		hook(chatID)
+	}
+}
+
 // AddSong adds a track to a chat's queue and returns the new queue length.
 func (c *ChatCacher) AddSong(chatID int64, song *utils.CachedTrack) int {
+	defer c.notify(chatID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	data := c.getOrCreate(chatID)
-	data.Queue = append(data.Queue, song)
+	if data.Fair {
+		data.Queue = fairInsert(data.Queue, song)
+	} else {
+		data.Queue = append(data.Queue, song)
+	}
 	return len(data.Queue)
 }
 
 // AddSongs appends multiple tracks to a chat's queue and returns the new queue length.
 func (c *ChatCacher) AddSongs(chatID int64, songs []*utils.CachedTrack) int {
+	defer c.notify(chatID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	data := c.getOrCreate(chatID)
-	data.Queue = append(data.Queue, songs...)
+	if !data.Fair {
+		data.Queue = append(data.Queue, songs...)
+		return len(data.Queue)
+	}
+	for _, song := range songs {
+		data.Queue = fairInsert(data.Queue, song)
+	}
 	return len(data.Queue)
 }
 
+// QueueLimits caps the size of a chat's queue. A zero MaxPerUser or MaxDuration means no cap.
+type QueueLimits struct {
+	MaxTracks   int
+	MaxPerUser  int
+	MaxDuration int
+}
+
+// Reasons AddSongsLimited gives for the tracks it did not add.
+var (
+	ErrQueueFull     = errors.New("queue is full")
+	ErrUserQueueFull = errors.New("requester has too many queued tracks")
+	ErrQueueTooLong  = errors.New("queue duration limit reached")
+)
+
+// assumedDuration is what a track of unknown length counts toward a queue duration limit.
+const assumedDuration = 5 * 60
+
+// limitSeconds returns how long a track counts toward a queue duration limit. Live streams
+// have no length and count as nothing.
+func limitSeconds(t *utils.CachedTrack) int {
+	switch {
+	case t.Duration > 0:
+		return t.Duration
+	case t.IsLive:
+		return 0
+	default:
+		return assumedDuration
+	}
+}
+
+// AddSongsLimited adds the leading tracks that fit within the limits on top of the queue, in
+// one step so concurrent requests cannot overfill it. With front the tracks go right after the
+// playing one, in order. It returns how many tracks were added, the new queue length and,
+// when some did not fit, the reason.
+func (c *ChatCacher) AddSongsLimited(chatID int64, songs []*utils.CachedTrack, limits QueueLimits, front bool) (int, int, error) {
+	defer c.notify(chatID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.getOrCreate(chatID)
+	count, duration := len(data.Queue), 0
+	perUser := make(map[int64]int)
+	for _, t := range data.Queue {
+		duration += limitSeconds(t)
+		perUser[t.UserID]++
+	}
+
+	n := len(songs)
+	var err error
+	for i, t := range songs {
+		switch {
+		case count >= limits.MaxTracks:
+			err = ErrQueueFull
+		case limits.MaxPerUser > 0 && perUser[t.UserID] >= limits.MaxPerUser:
+			err = ErrUserQueueFull
+		case limits.MaxDuration > 0 && duration+limitSeconds(t) > limits.MaxDuration:
+			err = ErrQueueTooLong
+		}
+		if err != nil {
+			n = i
+			break
+		}
+		count++
+		perUser[t.UserID]++
+		duration += limitSeconds(t)
+	}
+
+	fit := songs[:n]
+	switch {
+	case front && len(data.Queue) > 0:
+		data.Queue = slices.Insert(data.Queue, 1, fit...)
+	case data.Fair:
+		for _, song := range fit {
+			data.Queue = fairInsert(data.Queue, song)
+		}
+	default:
+		data.Queue = append(data.Queue, fit...)
+	}
+	return n, len(data.Queue), err
+}
+
 // AddSongToFront inserts a song after the currently playing song (at index 1).
 // If the queue is empty, it's the same as AddSong.
 func (c *ChatCacher) AddSongToFront(chatID int64, song *utils.CachedTrack) int {
+	defer c.notify(chatID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -85,6 +242,7 @@ func (c *ChatCacher) AddSongToFront(chatID int64, song *utils.CachedTrack) int {
 
 // MoveTrackToFront moves a track with the given trackID to the front (after the current song).
 func (c *ChatCacher) MoveTrackToFront(chatID int64, trackID string) bool {
+	defer c.notify(chatID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -146,6 +304,7 @@ func (c *ChatCacher) GetUpcomingTrack(chatID int64) *utils.CachedTrack {
 
 // RemoveCurrentSong removes and returns the currently playing track, or nil if the queue is empty.
 func (c *ChatCacher) RemoveCurrentSong(chatID int64) *utils.CachedTrack {
+	defer c.notify(chatID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -163,8 +322,104 @@ func (c *ChatCacher) RemoveCurrentSong(chatID int64) *utils.CachedTrack {
 	return removed
 }
 
+// AdvanceQueue finishes the playing track according to the chat's repeat mode and
+// returns the track that should play next, or nil if the queue is now empty.
+//
+// With RepeatTrack the playing track is kept, with RepeatQueue it is moved to the end
+// of the queue, and otherwise it is removed. When skip is true, RepeatTrack behaves
+// like RepeatOff so a skipped track is not replayed.
+func (c *ChatCacher) AdvanceQueue(chatID int64, skip bool) *utils.CachedTrack {
+	defer c.notify(chatID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.chatCache[chatID]
+	if !ok || len(data.Queue) == 0 {
+		return nil
+	}
+
+	finished := data.Queue[0]
+	if finished.Platform == utils.YouTube {
+		data.LastYouTubeTrack = finished
+	}
+
+	// A live ingest ends with its stream, so it is neither kept in the history nor repeated.
+	ingest := finished.Platform == utils.Ingest
+	if !ingest && (data.Repeat != RepeatTrack || skip) {
+		c.pushHistory(chatID, finished)
+	}
+
+	switch {
+	case ingest:
+		data.Queue[0] = nil
+		data.Queue = data.Queue[1:]
+	case data.Repeat == RepeatTrack && !skip:
+	case data.Repeat == RepeatQueue:
+		copy(data.Queue, data.Queue[1:])
+		data.Queue[len(data.Queue)-1] = finished
+	default:
+		data.Queue[0] = nil
+		data.Queue = data.Queue[1:]
+	}
+
+	if len(data.Queue) == 0 {
+		return nil
+	}
+	return data.Queue[0]
+}
+
+// pushHistory records a finished track, dropping the oldest entry once the history is full.
+// Caller must hold the write lock.
+func (c *ChatCacher) pushHistory(chatID int64, track *utils.CachedTrack) {
+	played := *track
+	played.Loop = 0
+
+	h := append(c.history[chatID], &played)
+	if len(h) > historySize {
+		h[0] = nil
+		h = h[1:]
+	}
+	c.history[chatID] = h
+}
+
+// GetHistory returns the recently played tracks of a chat, most recent first.
+func (c *ChatCacher) GetHistory(chatID int64) []*utils.CachedTrack {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	h := c.history[chatID]
+	out := make([]*utils.CachedTrack, len(h))
+	for i, t := range h {
+		out[len(h)-1-i] = t
+	}
+	return out
+}
+
+// PlayPrevious takes the most recently played track out of the history and puts it at
+// the head of the queue, pushing the playing track back to index 1. It returns the track
+// to play, or nil if the history is empty.
+func (c *ChatCacher) PlayPrevious(chatID int64) *utils.CachedTrack {
+	defer c.notify(chatID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := c.history[chatID]
+	if len(h) == 0 {
+		return nil
+	}
+
+	track := h[len(h)-1]
+	h[len(h)-1] = nil
+	c.history[chatID] = h[:len(h)-1]
+
+	data := c.getOrCreate(chatID)
+	data.Queue = append([]*utils.CachedTrack{track}, data.Queue...)
+	return track
+}
+
 // RemoveTrack removes the track at the given index and returns whether it succeeded.
 func (c *ChatCacher) RemoveTrack(chatID int64, index int) bool {
+	defer c.notify(chatID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -180,6 +435,102 @@ func (c *ChatCacher) RemoveTrack(chatID int64, index int) bool {
 	return true
 }
 
+// MoveTrack moves the upcoming track at index from to index to, shifting the tracks in between.
+// Index 0 is the playing track and cannot be moved or replaced.
+func (c *ChatCacher) MoveTrack(chatID int64, from, to int) bool {
+	defer c.notify(chatID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.chatCache[chatID]
+	if !ok || from < 1 || to < 1 || from >= len(data.Queue) || to >= len(data.Queue) {
+		return false
+	}
+
+	q := data.Queue
+	track := q[from]
+	if from < to {
+		copy(q[from:to], q[from+1:to+1])
+	} else {
+		copy(q[to+1:from+1], q[to:from])
+	}
+	q[to] = track
+	return true
+}
+
+// ShuffleQueue randomly reorders the upcoming tracks, keeping the playing track in place.
+// It returns the number of tracks that were shuffled.
+func (c *ChatCacher) ShuffleQueue(chatID int64) int {
+	defer c.notify(chatID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.chatCache[chatID]
+	if !ok || len(data.Queue) < 3 {
+		return 0
+	}
+
+	upcoming := data.Queue[1:]
+	rand.Shuffle(len(upcoming), func(i, j int) {
+		upcoming[i], upcoming[j] = upcoming[j], upcoming[i]
+	})
+	return len(upcoming)
+}
+
+// SkipTo drops the upcoming tracks before index so that the track at index plays next.
+// When the whole queue repeats, the skipped tracks move to the end of the queue instead, so
+// they stay in the rotation. The loop count of the playing track is reset so the following
+// PlayNext advances the queue.
+func (c *ChatCacher) SkipTo(chatID int64, index int) bool {
+	defer c.notify(chatID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.chatCache[chatID]
+	if !ok || index < 1 || index >= len(data.Queue) {
+		return false
+	}
+
+	q := data.Queue
+	skipped := append([]*utils.CachedTrack(nil), q[1:index]...)
+	end := 1 + copy(q[1:], q[index:])
+	if data.Repeat == RepeatQueue {
+		for _, t := range skipped {
+			if t.Platform != utils.Ingest {
+				q[end] = t
+				end++
+			}
+		}
+	}
+	for i := end; i < len(q); i++ {
+		q[i] = nil
+	}
+	data.Queue = q[:end]
+	data.Queue[0].Loop = 0
+	return true
+}
+
+// ClearQueue removes every upcoming track while keeping the playing one. The tracks leave the
+// rotation of a repeating queue as well, since clearing them is asked for explicitly.
+// It returns the number of tracks removed.
+func (c *ChatCacher) ClearQueue(chatID int64) int {
+	defer c.notify(chatID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.chatCache[chatID]
+	if !ok || len(data.Queue) < 2 {
+		return 0
+	}
+
+	removed := len(data.Queue) - 1
+	for i := 1; i < len(data.Queue); i++ {
+		data.Queue[i] = nil
+	}
+	data.Queue = data.Queue[:1]
+	return removed
+}
+
 // IsActive returns true if the chat has at least one queued track.
 func (c *ChatCacher) IsActive(chatID int64) bool {
 	c.mu.RLock()
@@ -200,6 +551,7 @@ func (c *ChatCacher) GetAutoplay(chatID int64) bool {
 
 // SetAutoplay sets the autoplay status for a chat.
 func (c *ChatCacher) SetAutoplay(chatID int64, state bool) {
+	defer c.notify(chatID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -207,6 +559,133 @@ func (c *ChatCacher) SetAutoplay(chatID int64, state bool) {
 	data.Autoplay = state
 }
 
+// GetRepeatMode returns the repeat mode for a chat.
+func (c *ChatCacher) GetRepeatMode(chatID int64) RepeatMode {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	data, ok := c.chatCache[chatID]
+	if !ok || data.Repeat == "" {
+		return RepeatOff
+	}
+	return data.Repeat
+}
+
+// SetRepeatMode sets the repeat mode for a chat.
+func (c *ChatCacher) SetRepeatMode(chatID int64, mode RepeatMode) {
+	defer c.notify(chatID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.getOrCreate(chatID)
+	data.Repeat = mode
+}
+
+// GetFairMode reports whether the chat's upcoming tracks are interleaved by requester.
+func (c *ChatCacher) GetFairMode(chatID int64) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	data, ok := c.chatCache[chatID]
+	return ok && data.Fair
+}
+
+// SetFairMode enables or disables fair ordering for a chat. Enabling it reorders the
+// upcoming tracks right away; tracks added later are slotted in as they arrive.
+func (c *ChatCacher) SetFairMode(chatID int64, enabled bool) {
+	defer c.notify(chatID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.chatCache[chatID]
+	if !ok {
+		if !enabled {
+			return
+		}
+		data = c.getOrCreate(chatID)
+	}
+
+	if enabled && !data.Fair {
+		interleave(data.Queue)
+	}
+	data.Fair = enabled
+}
+
+// requester identifies who asked for a track, preferring the user ID over the display name.
+type requester struct {
+	id   int64
+	name string
+}
+
+func requesterOf(t *utils.CachedTrack) requester {
+	if t.UserID != 0 {
+		return requester{id: t.UserID}
+	}
+	return requester{name: t.User}
+}
+
+// interleave reorders the upcoming tracks (everything after index 0) round-robin by
+// requester while keeping each requester's own tracks in order. The requester of the
+// playing track is served last in the first round.
+func interleave(queue []*utils.CachedTrack) {
+	if len(queue) < 3 {
+		return
+	}
+
+	upcoming := queue[1:]
+	var order []requester
+	byRequester := make(map[requester][]*utils.CachedTrack)
+	for _, t := range upcoming {
+		r := requesterOf(t)
+		if _, ok := byRequester[r]; !ok {
+			order = append(order, r)
+		}
+		byRequester[r] = append(byRequester[r], t)
+	}
+
+	current := requesterOf(queue[0])
+	for i, r := range order {
+		if r == current {
+			order = append(order[:i], order[i+1:]...)
+			order = append(order, current)
+			break
+		}
+	}
+
+	pos := 0
+	for round := 0; pos < len(upcoming); round++ {
+		for _, r := range order {
+			if tracks := byRequester[r]; round < len(tracks) {
+				upcoming[pos] = tracks[round]
+				pos++
+			}
+		}
+	}
+}
+
+// fairInsert adds a track to a fair queue at the end of its requester's next round, leaving
+// the queued tracks in their order. The playing track counts toward its requester's rounds.
+func fairInsert(queue []*utils.CachedTrack, song *utils.CachedTrack) []*utils.CachedTrack {
+	r := requesterOf(song)
+	round := 0
+	for _, t := range queue {
+		if requesterOf(t) == r {
+			round++
+		}
+	}
+
+	pos := 0
+	seen := make(map[requester]int)
+	for i, t := range queue {
+		tr := requesterOf(t)
+		if seen[tr] <= round {
+			pos = i + 1
+		}
+		seen[tr]++
+	}
+	return slices.Insert(queue, pos, song)
+}
+
 // GetLastYouTubeTrack returns the last played YouTube track for a chat.
 func (c *ChatCacher) GetLastYouTubeTrack(chatID int64) *utils.CachedTrack {
 	c.mu.RLock()
@@ -221,6 +700,7 @@ func (c *ChatCacher) GetLastYouTubeTrack(chatID int64) *utils.CachedTrack {
 
 // ClearChat deletes all queued tracks for a chat.
 func (c *ChatCacher) ClearChat(chatID int64) {
+	defer c.notify(chatID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -259,6 +739,7 @@ func (c *ChatCacher) GetLoopCount(chatID int64) int {
 // SetLoopCount sets the loop count on the currently playing track.
 // Returns false if there is no active track.
 func (c *ChatCacher) SetLoopCount(chatID int64, loop int) bool {
+	defer c.notify(chatID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -313,5 +794,103 @@ func (c *ChatCacher) GetTrackIfExists(chatID int64, trackID string) *utils.Cache
 	return nil
 }
 
-// ChatCache is the global instance.
-var ChatCache = newChatCacher()
+// SetTrackFile records the downloaded file of every queued track with the given trackID.
+// Returns false if no queued track matches.
+func (c *ChatCacher) SetTrackFile(chatID int64, trackID, filePath string) bool {
+	defer c.notify(chatID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.chatCache[chatID]
+	if !ok {
+		return false
+	}
+	found := false
+	for _, t := range data.Queue {
+		if t.TrackID == trackID {
+			t.FilePath = filePath
+			found = true
+		}
+	}
+	return found
+}
+
+// SetTrackDuration records the duration of every queued track with the given trackID, for
+// tracks whose duration is only known once downloaded. Returns false if no queued track matches.
+func (c *ChatCacher) SetTrackDuration(chatID int64, trackID string, duration int) bool {
+	defer c.notify(chatID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.chatCache[chatID]
+	if !ok {
+		return false
+	}
+	found := false
+	for _, t := range data.Queue {
+		if t.TrackID == trackID {
+			t.Duration = duration
+			found = true
+		}
+	}
+	return found
+}
+
+// Snapshot returns a copy of a chat's queue state, or nil if the chat has no tracks to save.
+// The tracks are copied so later queue changes do not affect the snapshot.
+func (c *ChatCacher) Snapshot(chatID int64) *ChatSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	data, ok := c.chatCache[chatID]
+	if !ok || len(data.Queue) == 0 {
+		return nil
+	}
+
+	snap := &ChatSnapshot{
+		ChatID:    chatID,
+		Autoplay:  data.Autoplay,
+		Repeat:    data.Repeat,
+		Fair:      data.Fair,
+		UpdatedAt: time.Now(),
+	}
+	// A live ingest cannot be resumed once its stream is gone, so it is not saved.
+	for _, t := range data.Queue {
+		if t.Platform == utils.Ingest {
+			continue
+		}
+		track := *t
+		snap.Queue = append(snap.Queue, &track)
+	}
+	if len(snap.Queue) == 0 {
+		return nil
+	}
+	if data.LastYouTubeTrack != nil {
+		track := *data.LastYouTubeTrack
+		snap.LastYouTubeTrack = &track
+	}
+	snap.History = append(snap.History, c.history[chatID]...)
+	return snap
+}
+
+// Restore replaces a chat's queue state with the contents of a snapshot.
+// Change hooks are not called, since the state already matches what was persisted.
+func (c *ChatCacher) Restore(snap *ChatSnapshot) {
+	if snap == nil || len(snap.Queue) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.chatCache[snap.ChatID] = &ChatData{
+		Queue:            append([]*utils.CachedTrack(nil), snap.Queue...),
+		Autoplay:         snap.Autoplay,
+		Repeat:           snap.Repeat,
+		Fair:             snap.Fair,
+		LastYouTubeTrack: snap.LastYouTubeTrack,
+	}
+	if len(snap.History) > 0 {
+		c.history[snap.ChatID] = append([]*utils.CachedTrack(nil), snap.History...)
+	}
+}
@@ -2,6 +2,8 @@ package cache
 
 import (
 	"ashokshau/tgmusic/src/utils"
+	"errors"
+	"fmt"
 	"sync"
 	"testing"
 )
@@ -83,6 +85,45 @@ func TestAddSongs_AppendToExisting(t *testing.T) {
 	}
 }
 
+// AddSongsLimited
+
+func TestAddSongsLimited_StopsAtLimit(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, userTrack("a", 1))
+	limits := QueueLimits{MaxTracks: 10, MaxPerUser: 2}
+	added, n, err := c.AddSongsLimited(1, []*utils.CachedTrack{userTrack("b", 1), userTrack("c", 1)}, limits, false)
+	if added != 1 || n != 2 || !errors.Is(err, ErrUserQueueFull) {
+		t.Fatalf("expected one track added before the per-user limit, got %d, %d, %v", added, n, err)
+	}
+	if added, _, err := c.AddSongsLimited(1, []*utils.CachedTrack{userTrack("d", 2)}, limits, false); added != 1 || err != nil {
+		t.Fatalf("expected another requester's track to fit, got %d, %v", added, err)
+	}
+}
+
+func TestAddSongsLimited_UnknownDurationCounts(t *testing.T) {
+	c := newCache()
+	unknown := userTrack("a", 1)
+	unknown.Duration = 0
+	live := userTrack("b", 1)
+	live.Duration, live.IsLive = 0, true
+	limits := QueueLimits{MaxTracks: 10, MaxDuration: assumedDuration}
+	added, _, err := c.AddSongsLimited(1, []*utils.CachedTrack{live, unknown, userTrack("c", 1)}, limits, false)
+	if added != 2 || !errors.Is(err, ErrQueueTooLong) {
+		t.Fatalf("expected an unknown duration to count toward the limit, got %d, %v", added, err)
+	}
+}
+
+func TestAddSongsLimited_Front(t *testing.T) {
+	c := newCache()
+	c.AddSongs(1, []*utils.CachedTrack{makeTrack("a", "a"), makeTrack("b", "b")})
+	if _, n, err := c.AddSongsLimited(1, []*utils.CachedTrack{makeTrack("x", "x"), makeTrack("y", "y")}, QueueLimits{MaxTracks: 10}, true); n != 4 || err != nil {
+		t.Fatalf("expected 4 tracks, got %d, %v", n, err)
+	}
+	if got := queueIDs(c, 1); got != "axyb" {
+		t.Fatalf("expected axyb, got %s", got)
+	}
+}
+
 // GetPlayingTrack
 
 func TestGetPlayingTrack_Empty(t *testing.T) {
@@ -173,6 +214,300 @@ func TestRemoveCurrentSong_UntilEmpty(t *testing.T) {
 	}
 }
 
+// AdvanceQueue
+
+func TestAdvanceQueue_Off(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("a", "a"))
+	c.AddSong(1, makeTrack("b", "b"))
+	next := c.AdvanceQueue(1, false)
+	if next == nil || next.TrackID != "b" {
+		t.Fatal("expected b to play next")
+	}
+	if got := queueIDs(c, 1); got != "b" {
+		t.Fatalf("expected b, got %s", got)
+	}
+	if c.GetLastYouTubeTrack(1).TrackID != "a" {
+		t.Fatal("expected a to be recorded as last YouTube track")
+	}
+}
+
+func TestAdvanceQueue_OffLastTrack(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("a", "a"))
+	if next := c.AdvanceQueue(1, false); next != nil {
+		t.Fatal("expected nil when the queue runs out")
+	}
+	if c.IsActive(1) {
+		t.Fatal("expected chat to be inactive")
+	}
+}
+
+func TestAdvanceQueue_RepeatTrack(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("a", "a"))
+	c.AddSong(1, makeTrack("b", "b"))
+	c.SetRepeatMode(1, RepeatTrack)
+	if next := c.AdvanceQueue(1, false); next == nil || next.TrackID != "a" {
+		t.Fatal("expected a to repeat")
+	}
+	if next := c.AdvanceQueue(1, true); next == nil || next.TrackID != "b" {
+		t.Fatal("expected skip to move on to b")
+	}
+}
+
+func TestAdvanceQueue_RepeatQueue(t *testing.T) {
+	c := newCache()
+	for _, id := range []string{"a", "b", "c"} {
+		c.AddSong(1, makeTrack(id, id))
+	}
+	c.SetRepeatMode(1, RepeatQueue)
+	c.AdvanceQueue(1, false)
+	if got := queueIDs(c, 1); got != "bca" {
+		t.Fatalf("expected bca, got %s", got)
+	}
+	c.AdvanceQueue(1, true)
+	if got := queueIDs(c, 1); got != "cab" {
+		t.Fatalf("expected cab, got %s", got)
+	}
+}
+
+func TestSkipTo_RepeatQueue(t *testing.T) {
+	c := newCache()
+	for _, id := range []string{"a", "b", "c", "d"} {
+		c.AddSong(1, makeTrack(id, id))
+	}
+	c.SetRepeatMode(1, RepeatQueue)
+	if !c.SkipTo(1, 3) {
+		t.Fatal("expected SkipTo to succeed")
+	}
+	if got := queueIDs(c, 1); got != "adbc" {
+		t.Fatalf("expected the skipped tracks at the end, got %s", got)
+	}
+	c.AdvanceQueue(1, true)
+	if got := queueIDs(c, 1); got != "dbca" {
+		t.Fatalf("expected dbca, got %s", got)
+	}
+
+	c.SetRepeatMode(1, RepeatOff)
+	c.SkipTo(1, 2)
+	if got := queueIDs(c, 1); got != "dca" {
+		t.Fatalf("expected the skipped track to be dropped without repeat, got %s", got)
+	}
+}
+
+func TestAdvanceQueue_IngestNotRepeated(t *testing.T) {
+	c := newCache()
+	ingest := makeTrack("live", "live")
+	ingest.Platform = utils.Ingest
+	c.AddSong(1, ingest)
+	c.AddSong(1, makeTrack("a", "a"))
+	c.SetRepeatMode(1, RepeatQueue)
+	if snap := c.Snapshot(1); snap == nil || len(snap.Queue) != 1 || snap.Queue[0].TrackID != "a" {
+		t.Fatal("expected the snapshot to leave out the ingest")
+	}
+	c.AdvanceQueue(1, false)
+	if got := queueIDs(c, 1); got != "a" {
+		t.Fatalf("expected the ingest to leave the rotation, got %s", got)
+	}
+	if h := c.GetHistory(1); len(h) != 0 {
+		t.Fatalf("expected no history for the ingest, got %d entries", len(h))
+	}
+}
+
+func TestAdvanceQueue_Empty(t *testing.T) {
+	c := newCache()
+	if next := c.AdvanceQueue(1, false); next != nil {
+		t.Fatal("expected nil for unknown chat")
+	}
+}
+
+// RepeatMode
+
+func TestGetRepeatMode_Default(t *testing.T) {
+	c := newCache()
+	if c.GetRepeatMode(1) != RepeatOff {
+		t.Fatal("expected repeat to be off by default")
+	}
+}
+
+func TestRepeatMode_LoadedAfterClear(t *testing.T) {
+	LoadDefaults = func(int64) ChatDefaults { return ChatDefaults{Repeat: RepeatQueue} }
+	defer func() { LoadDefaults = nil }()
+
+	c := newCache()
+	c.AddSong(1, makeTrack("a", "a"))
+	if c.GetRepeatMode(1) != RepeatQueue {
+		t.Fatal("expected the saved repeat mode for a new queue")
+	}
+	c.SetRepeatMode(1, RepeatTrack)
+	c.ClearChat(1)
+	c.AddSong(1, makeTrack("b", "b"))
+	if c.GetRepeatMode(1) != RepeatQueue {
+		t.Fatal("expected the saved repeat mode after ClearChat")
+	}
+}
+
+// Fair mode
+
+func userTrack(id string, userID int64) *utils.CachedTrack {
+	t := makeTrack(id, id)
+	t.UserID = userID
+	return t
+}
+
+func TestFairMode_InterleavesRequesters(t *testing.T) {
+	c := newCache()
+	c.SetFairMode(1, true)
+	c.AddSong(1, userTrack("a0", 1))
+	c.AddSongs(1, []*utils.CachedTrack{userTrack("a1", 1), userTrack("a2", 1), userTrack("a3", 1)})
+	c.AddSong(1, userTrack("b1", 2))
+	c.AddSong(1, userTrack("c1", 3))
+	if got := queueIDs(c, 1); got != "a0b1c1a1a2a3" {
+		t.Fatalf("expected a0b1c1a1a2a3, got %s", got)
+	}
+	if c.GetUpcomingTrack(1).TrackID != "b1" {
+		t.Fatal("expected b1 to be up next")
+	}
+}
+
+func TestFairMode_KeepsRequesterOrder(t *testing.T) {
+	c := newCache()
+	c.SetFairMode(1, true)
+	c.AddSong(1, userTrack("x", 9))
+	c.AddSongs(1, []*utils.CachedTrack{userTrack("a1", 1), userTrack("a2", 1)})
+	c.AddSongs(1, []*utils.CachedTrack{userTrack("b1", 2), userTrack("b2", 2)})
+	if got := queueIDs(c, 1); got != "xa1b1a2b2" {
+		t.Fatalf("expected xa1b1a2b2, got %s", got)
+	}
+}
+
+func TestFairMode_EnableReorders(t *testing.T) {
+	c := newCache()
+	c.AddSongs(1, []*utils.CachedTrack{userTrack("a0", 1), userTrack("a1", 1), userTrack("a2", 1), userTrack("b1", 2)})
+	c.SetFairMode(1, true)
+	if got := queueIDs(c, 1); got != "a0b1a1a2" {
+		t.Fatalf("expected a0b1a1a2, got %s", got)
+	}
+	if !c.GetFairMode(1) {
+		t.Fatal("expected fair mode to be enabled")
+	}
+}
+
+func TestFairMode_AddKeepsManualOrder(t *testing.T) {
+	c := newCache()
+	c.SetFairMode(1, true)
+	c.AddSongs(1, []*utils.CachedTrack{userTrack("x", 9), userTrack("a1", 1), userTrack("b1", 2), userTrack("a2", 1)})
+	if !c.MoveTrack(1, 3, 1) {
+		t.Fatal("expected MoveTrack to succeed")
+	}
+	c.AddSong(1, userTrack("c1", 3))
+	if got := queueIDs(c, 1); got != "xa2a1b1c1" {
+		t.Fatalf("expected the moved track to stay put, got %s", got)
+	}
+}
+
+func TestFairMode_LoadedForNewChats(t *testing.T) {
+	LoadDefaults = func(chatID int64) ChatDefaults { return ChatDefaults{Fair: chatID == 1} }
+	defer func() { LoadDefaults = nil }()
+
+	c := newCache()
+	c.AddSong(1, userTrack("a0", 1))
+	c.AddSongs(1, []*utils.CachedTrack{userTrack("a1", 1), userTrack("b1", 2)})
+	if got := queueIDs(c, 1); got != "a0b1a1" {
+		t.Fatalf("expected the saved fair setting to apply, got %s", got)
+	}
+	if c.GetFairMode(2) {
+		t.Fatal("expected fair mode off for a chat without the setting")
+	}
+}
+
+func TestFairMode_Off(t *testing.T) {
+	c := newCache()
+	c.AddSongs(1, []*utils.CachedTrack{userTrack("a0", 1), userTrack("a1", 1), userTrack("b1", 2)})
+	c.AddSong(1, userTrack("a2", 1))
+	if got := queueIDs(c, 1); got != "a0a1b1a2" {
+		t.Fatalf("expected FIFO order, got %s", got)
+	}
+	c.SetFairMode(2, false)
+	if len(c.GetActiveChats()) != 1 {
+		t.Fatal("disabling fair mode should not create chat state")
+	}
+}
+
+// History
+
+func TestHistory_RecordsFinishedTracks(t *testing.T) {
+	c := newCache()
+	for _, id := range []string{"a", "b", "c"} {
+		c.AddSong(1, makeTrack(id, id))
+	}
+	c.AdvanceQueue(1, false)
+	c.AdvanceQueue(1, false)
+	h := c.GetHistory(1)
+	if len(h) != 2 || h[0].TrackID != "b" || h[1].TrackID != "a" {
+		t.Fatalf("expected history b, a; got %v", h)
+	}
+}
+
+func TestHistory_Bounded(t *testing.T) {
+	c := newCache()
+	for i := 0; i < historySize+5; i++ {
+		c.AddSong(1, makeTrack("t", "Track"))
+		c.AdvanceQueue(1, false)
+	}
+	if n := len(c.GetHistory(1)); n != historySize {
+		t.Fatalf("expected %d history entries, got %d", historySize, n)
+	}
+}
+
+func TestHistory_SurvivesClearChat(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("a", "a"))
+	c.AddSong(1, makeTrack("b", "b"))
+	c.AdvanceQueue(1, false)
+	c.ClearChat(1)
+	if len(c.GetHistory(1)) != 1 {
+		t.Fatal("expected history to survive ClearChat")
+	}
+}
+
+func TestHistory_RepeatTrackNotRecorded(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("a", "a"))
+	c.SetRepeatMode(1, RepeatTrack)
+	c.AdvanceQueue(1, false)
+	if len(c.GetHistory(1)) != 0 {
+		t.Fatal("a repeating track should not be recorded until it leaves")
+	}
+}
+
+// PlayPrevious
+
+func TestPlayPrevious(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("a", "a"))
+	c.AddSong(1, makeTrack("b", "b"))
+	c.AdvanceQueue(1, false)
+	prev := c.PlayPrevious(1)
+	if prev == nil || prev.TrackID != "a" {
+		t.Fatal("expected a to be returned")
+	}
+	if got := queueIDs(c, 1); got != "ab" {
+		t.Fatalf("expected ab, got %s", got)
+	}
+	if len(c.GetHistory(1)) != 0 {
+		t.Fatal("expected history to be empty")
+	}
+}
+
+func TestPlayPrevious_EmptyHistory(t *testing.T) {
+	c := newCache()
+	if c.PlayPrevious(1) != nil {
+		t.Fatal("expected nil with no history")
+	}
+}
+
 // RemoveTrack
 
 func TestRemoveTrack_ValidIndex(t *testing.T) {
@@ -213,6 +548,134 @@ func TestRemoveTrack_EmptyQueue(t *testing.T) {
 	}
 }
 
+// MoveTrack
+
+func queueIDs(c QueueStore, chatID int64) string {
+	var ids string
+	for _, t := range c.GetQueue(chatID) {
+		ids += t.TrackID
+	}
+	return ids
+}
+
+func TestMoveTrack_Down(t *testing.T) {
+	c := newCache()
+	for _, id := range []string{"a", "b", "c", "d"} {
+		c.AddSong(1, makeTrack(id, id))
+	}
+	if !c.MoveTrack(1, 1, 3) {
+		t.Fatal("expected MoveTrack to succeed")
+	}
+	if got := queueIDs(c, 1); got != "acdb" {
+		t.Fatalf("expected acdb, got %s", got)
+	}
+}
+
+func TestMoveTrack_Up(t *testing.T) {
+	c := newCache()
+	for _, id := range []string{"a", "b", "c", "d"} {
+		c.AddSong(1, makeTrack(id, id))
+	}
+	if !c.MoveTrack(1, 3, 1) {
+		t.Fatal("expected MoveTrack to succeed")
+	}
+	if got := queueIDs(c, 1); got != "adbc" {
+		t.Fatalf("expected adbc, got %s", got)
+	}
+}
+
+func TestMoveTrack_InvalidIndex(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("a", "a"))
+	c.AddSong(1, makeTrack("b", "b"))
+	if c.MoveTrack(1, 0, 1) {
+		t.Fatal("should not move the playing track")
+	}
+	if c.MoveTrack(1, 1, 5) {
+		t.Fatal("should not move out of range")
+	}
+	if c.MoveTrack(2, 1, 1) {
+		t.Fatal("should fail for unknown chat")
+	}
+}
+
+// ShuffleQueue
+
+func TestShuffleQueue_KeepsCurrent(t *testing.T) {
+	c := newCache()
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		c.AddSong(1, makeTrack(id, id))
+	}
+	if n := c.ShuffleQueue(1); n != 4 {
+		t.Fatalf("expected 4 shuffled tracks, got %d", n)
+	}
+	if c.GetPlayingTrack(1).TrackID != "a" {
+		t.Fatal("playing track should stay in place")
+	}
+	if c.GetQueueLength(1) != 5 {
+		t.Fatal("shuffle should not change queue length")
+	}
+}
+
+func TestShuffleQueue_TooShort(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("a", "a"))
+	c.AddSong(1, makeTrack("b", "b"))
+	if n := c.ShuffleQueue(1); n != 0 {
+		t.Fatalf("expected nothing to shuffle, got %d", n)
+	}
+}
+
+// SkipTo
+
+func TestSkipTo(t *testing.T) {
+	c := newCache()
+	for _, id := range []string{"a", "b", "c", "d"} {
+		c.AddSong(1, makeTrack(id, id))
+	}
+	c.SetLoopCount(1, 3)
+	if !c.SkipTo(1, 3) {
+		t.Fatal("expected SkipTo to succeed")
+	}
+	if got := queueIDs(c, 1); got != "ad" {
+		t.Fatalf("expected ad, got %s", got)
+	}
+	if c.GetLoopCount(1) != 0 {
+		t.Fatal("expected loop count to be reset")
+	}
+}
+
+func TestSkipTo_InvalidIndex(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("a", "a"))
+	c.AddSong(1, makeTrack("b", "b"))
+	if c.SkipTo(1, 0) || c.SkipTo(1, 2) {
+		t.Fatal("expected SkipTo to fail for out-of-range index")
+	}
+}
+
+// ClearQueue
+
+func TestClearQueue_KeepsCurrent(t *testing.T) {
+	c := newCache()
+	for _, id := range []string{"a", "b", "c"} {
+		c.AddSong(1, makeTrack(id, id))
+	}
+	if n := c.ClearQueue(1); n != 2 {
+		t.Fatalf("expected 2 removed, got %d", n)
+	}
+	if got := queueIDs(c, 1); got != "a" {
+		t.Fatalf("expected only a to remain, got %s", got)
+	}
+}
+
+func TestClearQueue_Empty(t *testing.T) {
+	c := newCache()
+	if n := c.ClearQueue(1); n != 0 {
+		t.Fatalf("expected 0 removed, got %d", n)
+	}
+}
+
 // IsActive
 
 func TestIsActive_False(t *testing.T) {
@@ -391,6 +854,204 @@ func TestGetTrackIfExists_UnknownChat(t *testing.T) {
 	}
 }
 
+// SetTrackFile
+
+func TestSetTrackFile(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("t1", "Track 1"))
+	c.AddSong(1, makeTrack("t2", "Track 2"))
+
+	if !c.SetTrackFile(1, "t2", "/tmp/t2.mp3") {
+		t.Fatal("expected SetTrackFile to find t2")
+	}
+	if got := c.GetUpcomingTrack(1); got.FilePath != "/tmp/t2.mp3" {
+		t.Fatalf("expected file path to be set, got %q", got.FilePath)
+	}
+	if c.SetTrackFile(1, "nope", "/tmp/x.mp3") {
+		t.Fatal("expected SetTrackFile to fail for a missing track")
+	}
+}
+
+// FindDuplicate
+
+func TestFindDuplicate_SameURL(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("t1", "Track 1"))
+
+	dup := makeTrack("other", "Something Else")
+	dup.URL = "https://example.com/t1/"
+	if c.FindDuplicate(1, dup) == nil {
+		t.Fatal("expected tracks with the same URL to match")
+	}
+}
+
+func TestFindDuplicate_AcrossPlatforms(t *testing.T) {
+	c := newCache()
+	yt := makeTrack("dQw4w9WgXcQ", "Rick Astley - Never Gonna Give You Up (Official Music Video)")
+	yt.Channel = "RickAstleyVEVO"
+	yt.Duration = 213
+	c.AddSong(1, yt)
+
+	spotify := makeTrack("4PTG3Z6ehGkBFwjybzWkR8", "Never Gonna Give You Up")
+	spotify.Channel = "Rick Astley"
+	spotify.Duration = 214
+	spotify.Platform = utils.Spotify
+	if c.FindDuplicate(1, spotify) == nil {
+		t.Fatal("expected the Spotify track to match the YouTube video")
+	}
+
+	file := makeTrack("file-id", "Rick Astley - Never Gonna Give You Up.mp3")
+	file.Channel = ""
+	file.Duration = 212
+	file.Platform = utils.Telegram
+	if c.FindDuplicate(1, file) == nil {
+		t.Fatal("expected the Telegram file to match the YouTube video")
+	}
+}
+
+func TestFindDuplicate_DifferentVersion(t *testing.T) {
+	c := newCache()
+	original := makeTrack("t1", "Blinding Lights (Official Video)")
+	original.Channel = "The Weeknd"
+	original.Duration = 200
+	c.AddSong(1, original)
+
+	for i, name := range []string{"Blinding Lights (Live)", "Blinding Lights (Remix)", "Blinding Lights [Slowed + Reverb]", "Blinding Lights - Sped Up", "Blinding Lights (Acoustic)"} {
+		version := makeTrack(fmt.Sprintf("v%d", i), name)
+		version.URL = fmt.Sprintf("https://example.com/v%d", i)
+		version.Channel = "The Weeknd"
+		version.Duration = 204
+		if dup := c.FindDuplicate(1, version); dup != nil {
+			t.Fatalf("expected %q not to match %q", name, dup.Name)
+		}
+	}
+
+	live := makeTrack("t2", "Blinding Lights (Live)")
+	live.Channel = "The Weeknd"
+	live.Duration = 240
+	c.AddSong(1, live)
+	again := makeTrack("t3", "Blinding Lights [Live]")
+	again.URL = "https://example.com/t3"
+	again.Channel = "The Weeknd"
+	again.Duration = 242
+	if dup := c.FindDuplicate(1, again); dup == nil || dup.TrackID != "t2" {
+		t.Fatalf("expected the same live version to match, got %v", dup)
+	}
+}
+
+func TestFindDuplicate_DifferentDuration(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("t1", "Song"))
+
+	live := makeTrack("t2", "Song")
+	live.URL = "https://example.com/live"
+	live.Duration = 300
+	if c.FindDuplicate(1, live) != nil {
+		t.Fatal("expected tracks with very different durations not to match")
+	}
+}
+
+func TestFindDuplicate_DifferentSong(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("t1", "First Song"))
+
+	if c.FindDuplicate(1, makeTrack("t2", "Another Tune")) != nil {
+		t.Fatal("expected different songs not to match")
+	}
+}
+
+func TestFindDuplicate_UnknownDurationNeedsExactTitle(t *testing.T) {
+	c := newCache()
+	queued := makeTrack("t1", "Blue Sky Morning")
+	queued.Duration = 0
+	c.AddSong(1, queued)
+
+	partial := makeTrack("t2", "Blue Sky")
+	partial.Channel = "TestChannel"
+	if c.FindDuplicate(1, partial) != nil {
+		t.Fatal("expected a partial title match without durations not to match")
+	}
+
+	exact := makeTrack("t3", "Blue Sky Morning (Lyrics)")
+	if c.FindDuplicate(1, exact) == nil {
+		t.Fatal("expected an exact title match to match")
+	}
+}
+
+// Snapshot / Restore
+
+func TestSnapshot_Empty(t *testing.T) {
+	c := newCache()
+	if snap := c.Snapshot(1); snap != nil {
+		t.Fatal("expected nil snapshot for unknown chat")
+	}
+}
+
+func TestSnapshot_CopiesTracks(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("t1", "Track 1"))
+	c.SetAutoplay(1, true)
+	snap := c.Snapshot(1)
+	if snap == nil || len(snap.Queue) != 1 || !snap.Autoplay || snap.ChatID != 1 {
+		t.Fatalf("unexpected snapshot: %+v", snap)
+	}
+	c.SetLoopCount(1, 5)
+	if snap.Queue[0].Loop != 0 {
+		t.Fatal("snapshot track should not change with the queue")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	c := newCache()
+	c.AddSong(1, makeTrack("t1", "Track 1"))
+	c.AddSong(1, makeTrack("t2", "Track 2"))
+	snap := c.Snapshot(1)
+
+	r := newCache()
+	r.Restore(snap)
+	if r.GetQueueLength(1) != 2 {
+		t.Fatalf("expected 2 restored tracks, got %d", r.GetQueueLength(1))
+	}
+	if r.GetPlayingTrack(1).TrackID != "t1" {
+		t.Fatal("expected t1 to be playing after restore")
+	}
+}
+
+func TestRestore_Nil(t *testing.T) {
+	c := newCache()
+	c.Restore(nil)
+	if len(c.GetActiveChats()) != 0 {
+		t.Fatal("expected no active chats")
+	}
+}
+
+// OnChange
+
+func TestOnChange_Called(t *testing.T) {
+	c := newCache()
+	var got []int64
+	c.OnChange(func(chatID int64) {
+		got = append(got, chatID)
+	})
+	c.AddSong(1, makeTrack("t1", "Track 1"))
+	c.ClearChat(2)
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("unexpected hook calls: %v", got)
+	}
+}
+
+func TestOnChange_CanReadCache(t *testing.T) {
+	c := newCache()
+	var length int
+	c.OnChange(func(chatID int64) {
+		length = c.GetQueueLength(chatID)
+	})
+	c.AddSong(1, makeTrack("t1", "Track 1"))
+	if length != 1 {
+		t.Fatalf("expected hook to see queue length 1, got %d", length)
+	}
+}
+
 // Concurrency
 
 func TestConcurrentAddAndRead(t *testing.T) {
@@ -0,0 +1,161 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package cache
+
+import (
+	"ashokshau/tgmusic/src/utils"
+	"path"
+	"strings"
+	"unicode"
+)
+
+const (
+	// durationTolerance is how many seconds two versions of a song may differ by.
+	durationTolerance = 7
+	// minTitleSimilarity is the share of words two tracks must have in common.
+	minTitleSimilarity = 0.75
+)
+
+// noiseWords are dropped from titles before comparing them, as platforms add them inconsistently.
+var noiseWords = map[string]struct{}{
+	"official": {}, "video": {}, "audio": {}, "music": {}, "lyrics": {}, "lyric": {},
+	"visualizer": {}, "hd": {}, "hq": {}, "4k": {}, "mv": {}, "topic": {}, "vevo": {},
+	"feat": {}, "ft": {}, "the": {}, "a": {},
+}
+
+// versionWords mark a different version of a song. They are kept even inside brackets, and
+// tracks only match when they name the same versions, so "Song (Live)" is not "Song".
+var versionWords = map[string]struct{}{
+	"live": {}, "remix": {}, "slowed": {}, "sped": {}, "reverb": {}, "nightcore": {},
+	"acoustic": {}, "unplugged": {}, "instrumental": {}, "karaoke": {}, "cover": {},
+}
+
+// mediaExtensions are stripped from Telegram file names used as track titles.
+var mediaExtensions = map[string]struct{}{
+	".mp3": {}, ".m4a": {}, ".flac": {}, ".wav": {}, ".ogg": {}, ".opus": {}, ".aac": {},
+	".mp4": {}, ".mkv": {}, ".webm": {}, ".mov": {},
+}
+
+// titleWords splits a title or artist name into lowercase words, ignoring bracketed
+// parts such as "(Official Video)", file extensions and noise words. Version words are
+// kept from bracketed parts too.
+func titleWords(s string) map[string]struct{} {
+	if _, ok := mediaExtensions[strings.ToLower(path.Ext(s))]; ok {
+		s = strings.TrimSuffix(s, path.Ext(s))
+	}
+
+	var b, bracketed strings.Builder
+	depth := 0
+	for _, r := range strings.ToLower(s) {
+		out := &b
+		if depth > 0 {
+			out = &bracketed
+		}
+		switch {
+		case r == '(' || r == '[' || r == '{':
+			depth++
+			bracketed.WriteRune(' ')
+		case r == ')' || r == ']' || r == '}':
+			depth = max(depth-1, 0)
+			bracketed.WriteRune(' ')
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			out.WriteRune(r)
+		default:
+			out.WriteRune(' ')
+		}
+	}
+
+	words := make(map[string]struct{})
+	for _, w := range strings.Fields(b.String()) {
+		w = strings.TrimSuffix(w, "vevo")
+		if _, noise := noiseWords[w]; noise || w == "" {
+			continue
+		}
+		words[w] = struct{}{}
+	}
+	for _, w := range strings.Fields(bracketed.String()) {
+		if _, version := versionWords[w]; version {
+			words[w] = struct{}{}
+		}
+	}
+	return words
+}
+
+// sameVersions reports whether two word sets name the same version words.
+func sameVersions(a, b map[string]struct{}) bool {
+	for w := range versionWords {
+		_, inA := a[w]
+		_, inB := b[w]
+		if inA != inB {
+			return false
+		}
+	}
+	return true
+}
+
+// similarity returns the Jaccard index of two word sets.
+func similarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for w := range a {
+		if _, ok := b[w]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// SameTrack reports whether a and b are the same song, even when they come from
+// different platforms. Tracks match on ID or URL, or when their title and artist
+// words mostly agree, they name the same versions and their durations are close.
+func SameTrack(a, b *utils.CachedTrack) bool {
+	if a.TrackID != "" && a.TrackID == b.TrackID {
+		return true
+	}
+	if a.URL != "" && strings.EqualFold(strings.TrimSuffix(a.URL, "/"), strings.TrimSuffix(b.URL, "/")) {
+		return true
+	}
+
+	threshold := minTitleSimilarity
+	if a.Duration > 0 && b.Duration > 0 {
+		diff := a.Duration - b.Duration
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > durationTolerance {
+			return false
+		}
+	} else {
+		// Without durations to compare, only accept an exact title match.
+		threshold = 1
+	}
+
+	wordsA := titleWords(a.Name + " " + a.Channel)
+	wordsB := titleWords(b.Name + " " + b.Channel)
+	return sameVersions(wordsA, wordsB) && similarity(wordsA, wordsB) >= threshold
+}
+
+// FindDuplicate returns the track in a chat's queue that is the same song as track, or nil.
+func (c *ChatCacher) FindDuplicate(chatID int64, track *utils.CachedTrack) *utils.CachedTrack {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	data, ok := c.chatCache[chatID]
+	if !ok {
+		return nil
+	}
+	for _, t := range data.Queue {
+		if SameTrack(t, track) {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,452 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package cache
+
+import (
+	"ashokshau/tgmusic/src/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// redisKeyPrefix namespaces every key the store writes.
+	redisKeyPrefix = "tgmusic:"
+	// redisPoolSize is the number of idle connections kept open.
+	redisPoolSize = 8
+	// redisMaxRetries is how often a write is retried when another process changed the chat first.
+	redisMaxRetries = 10
+)
+
+var errRedisConflict = errors.New("chat was modified concurrently too many times")
+
+// RedisStore is a QueueStore that keeps each chat's state as a JSON document on a Redis
+// server. Writes load the chat, apply the same logic as ChatCacher and store it back
+// inside a WATCH/MULTI/EXEC transaction, so concurrent processes never lose updates.
+// Change hooks only fire for changes made by this process.
+type RedisStore struct {
+	opts respOptions
+	pool chan *respConn
+
+	hooksMu sync.RWMutex
+	hooks   []func(chatID int64)
+}
+
+// NewRedisStore connects to the Redis server at rawURL and returns a store backed by it.
+func NewRedisStore(rawURL string) (*RedisStore, error) {
+	opts, err := parseRedisURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RedisStore{opts: opts, pool: make(chan *respConn, redisPoolSize)}
+	if err = r.withConn(func(conn *respConn) error {
+		_, err := conn.do("PING")
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RedisStore) chatKey(chatID int64) string {
+	return redisKeyPrefix + "chat:" + strconv.FormatInt(chatID, 10)
+}
+
+func (r *RedisStore) activeKey() string {
+	return redisKeyPrefix + "active"
+}
+
+// withConn runs fn with a pooled connection, discarding the connection if it broke.
+func (r *RedisStore) withConn(fn func(conn *respConn) error) error {
+	var conn *respConn
+	select {
+	case conn = <-r.pool:
+	default:
+		var err error
+		if conn, err = dialResp(r.opts); err != nil {
+			return err
+		}
+	}
+
+	err := fn(conn)
+	if conn.broken {
+		conn.close()
+		return err
+	}
+	select {
+	case r.pool <- conn:
+	default:
+		conn.close()
+	}
+	return err
+}
+
+// OnChange registers a hook that is called after this process modifies a chat's queue state.
+func (r *RedisStore) OnChange(hook func(chatID int64)) {
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
+	r.hooks = append(r.hooks, hook)
+}
+
+// notify calls the registered change hooks for a chat.
+func (r *RedisStore) notify(chatID int64) {
+	r.hooksMu.RLock()
+	hooks := r.hooks
+	r.hooksMu.RUnlock()
+
+	for _, hook := range hooks {
+		hook(chatID)
+	}
+}
+
+// load reads a chat's state into a ChatCacher holding only that chat.
+func (r *RedisStore) load(conn *respConn, chatID int64) (*ChatCacher, error) {
+	c := newChatCacher()
+	reply, err := conn.do("GET", r.chatKey(chatID))
+	if err != nil || reply == nil {
+		return c, err
+	}
+
+	raw, ok := reply.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply to GET: %T", reply)
+	}
+	var snap ChatSnapshot
+	if err = json.Unmarshal(raw, &snap); err != nil {
+		return nil, fmt.Errorf("invalid chat state: %w", err)
+	}
+	snap.ChatID = chatID
+	c.importChat(&snap)
+	return c, nil
+}
+
+// save writes a chat's state back in a transaction. It returns false when a watched key
+// changed since it was read and nothing was written.
+func (r *RedisStore) save(conn *respConn, chatID int64, c *ChatCacher) (bool, error) {
+	snap := c.exportChat(chatID)
+	member := strconv.FormatInt(chatID, 10)
+
+	if _, err := conn.do("MULTI"); err != nil {
+		return false, err
+	}
+	var err error
+	if snap == nil {
+		_, err = conn.do("DEL", r.chatKey(chatID))
+	} else {
+		var raw []byte
+		if raw, err = json.Marshal(snap); err != nil {
+			_, _ = conn.do("DISCARD")
+			return false, err
+		}
+		_, err = conn.do("SET", r.chatKey(chatID), string(raw))
+	}
+	if err == nil {
+		if snap != nil && len(snap.Queue) > 0 {
+			_, err = conn.do("SADD", r.activeKey(), member)
+		} else {
+			_, err = conn.do("SREM", r.activeKey(), member)
+		}
+	}
+	if err != nil {
+		_, _ = conn.do("DISCARD")
+		return false, err
+	}
+
+	reply, err := conn.do("EXEC")
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+// transact runs fn against a chat and stores the result, retrying from a fresh read
+// when another process modified the chat in the meantime.
+func transact[T any](r *RedisStore, chatID int64, fn func(c *ChatCacher) T) (T, error) {
+	var out T
+	err := r.withConn(func(conn *respConn) error {
+		for attempt := range redisMaxRetries {
+			if attempt > 0 {
+				// Back off for a random while so competing writers stop colliding.
+				time.Sleep(time.Duration(rand.IntN(5*attempt)+1) * time.Millisecond)
+			}
+			if _, err := conn.do("WATCH", r.chatKey(chatID)); err != nil {
+				return err
+			}
+			c, err := r.load(conn, chatID)
+			if err != nil {
+				_, _ = conn.do("UNWATCH")
+				return err
+			}
+
+			out = fn(c)
+			committed, err := r.save(conn, chatID, c)
+			if err != nil || committed {
+				return err
+			}
+		}
+		return errRedisConflict
+	})
+	return out, err
+}
+
+// update runs a modifying ChatCacher method against a chat and calls the change hooks once the
+// change is saved. If it could not be saved, update returns the zero value and no hook runs.
+func update[T any](r *RedisStore, chatID int64, fn func(c *ChatCacher) T) T {
+	out, err := transact(r, chatID, fn)
+	if err != nil {
+		slog.Warn("[QueueStore] Failed to update chat", "chatID", chatID, "error", err)
+		var zero T
+		return zero
+	}
+	r.notify(chatID)
+	return out
+}
+
+// view runs a read-only ChatCacher method against a chat.
+func view[T any](r *RedisStore, chatID int64, fn func(c *ChatCacher) T) T {
+	var out T
+	err := r.withConn(func(conn *respConn) error {
+		c, err := r.load(conn, chatID)
+		if err != nil {
+			return err
+		}
+		out = fn(c)
+		return nil
+	})
+	if err != nil {
+		slog.Warn("[QueueStore] Failed to read chat", "chatID", chatID, "error", err)
+	}
+	return out
+}
+
+func (r *RedisStore) AddSong(chatID int64, song *utils.CachedTrack) int {
+	return update(r, chatID, func(c *ChatCacher) int { return c.AddSong(chatID, song) })
+}
+
+func (r *RedisStore) AddSongs(chatID int64, songs []*utils.CachedTrack) int {
+	return update(r, chatID, func(c *ChatCacher) int { return c.AddSongs(chatID, songs) })
+}
+
+func (r *RedisStore) AddSongToFront(chatID int64, song *utils.CachedTrack) int {
+	return update(r, chatID, func(c *ChatCacher) int { return c.AddSongToFront(chatID, song) })
+}
+
+func (r *RedisStore) AddSongsLimited(chatID int64, songs []*utils.CachedTrack, limits QueueLimits, front bool) (int, int, error) {
+	type result struct {
+		added, length int
+		err           error
+	}
+	res := update(r, chatID, func(c *ChatCacher) result {
+		added, length, err := c.AddSongsLimited(chatID, songs, limits, front)
+		return result{added, length, err}
+	})
+	return res.added, res.length, res.err
+}
+
+func (r *RedisStore) MoveTrackToFront(chatID int64, trackID string) bool {
+	return update(r, chatID, func(c *ChatCacher) bool { return c.MoveTrackToFront(chatID, trackID) })
+}
+
+func (r *RedisStore) GetPlayingTrack(chatID int64) *utils.CachedTrack {
+	return view(r, chatID, func(c *ChatCacher) *utils.CachedTrack { return c.GetPlayingTrack(chatID) })
+}
+
+func (r *RedisStore) GetUpcomingTrack(chatID int64) *utils.CachedTrack {
+	return view(r, chatID, func(c *ChatCacher) *utils.CachedTrack { return c.GetUpcomingTrack(chatID) })
+}
+
+func (r *RedisStore) RemoveCurrentSong(chatID int64) *utils.CachedTrack {
+	return update(r, chatID, func(c *ChatCacher) *utils.CachedTrack { return c.RemoveCurrentSong(chatID) })
+}
+
+func (r *RedisStore) AdvanceQueue(chatID int64, skip bool) *utils.CachedTrack {
+	return update(r, chatID, func(c *ChatCacher) *utils.CachedTrack { return c.AdvanceQueue(chatID, skip) })
+}
+
+func (r *RedisStore) GetHistory(chatID int64) []*utils.CachedTrack {
+	return view(r, chatID, func(c *ChatCacher) []*utils.CachedTrack { return c.GetHistory(chatID) })
+}
+
+func (r *RedisStore) PlayPrevious(chatID int64) *utils.CachedTrack {
+	return update(r, chatID, func(c *ChatCacher) *utils.CachedTrack { return c.PlayPrevious(chatID) })
+}
+
+func (r *RedisStore) RemoveTrack(chatID int64, index int) bool {
+	return update(r, chatID, func(c *ChatCacher) bool { return c.RemoveTrack(chatID, index) })
+}
+
+func (r *RedisStore) MoveTrack(chatID int64, from, to int) bool {
+	return update(r, chatID, func(c *ChatCacher) bool { return c.MoveTrack(chatID, from, to) })
+}
+
+func (r *RedisStore) ShuffleQueue(chatID int64) int {
+	return update(r, chatID, func(c *ChatCacher) int { return c.ShuffleQueue(chatID) })
+}
+
+func (r *RedisStore) SkipTo(chatID int64, index int) bool {
+	return update(r, chatID, func(c *ChatCacher) bool { return c.SkipTo(chatID, index) })
+}
+
+func (r *RedisStore) ClearQueue(chatID int64) int {
+	return update(r, chatID, func(c *ChatCacher) int { return c.ClearQueue(chatID) })
+}
+
+func (r *RedisStore) IsActive(chatID int64) bool {
+	return view(r, chatID, func(c *ChatCacher) bool { return c.IsActive(chatID) })
+}
+
+func (r *RedisStore) GetAutoplay(chatID int64) bool {
+	return view(r, chatID, func(c *ChatCacher) bool { return c.GetAutoplay(chatID) })
+}
+
+func (r *RedisStore) SetAutoplay(chatID int64, state bool) {
+	update(r, chatID, func(c *ChatCacher) struct{} { c.SetAutoplay(chatID, state); return struct{}{} })
+}
+
+func (r *RedisStore) GetRepeatMode(chatID int64) RepeatMode {
+	return view(r, chatID, func(c *ChatCacher) RepeatMode { return c.GetRepeatMode(chatID) })
+}
+
+func (r *RedisStore) SetRepeatMode(chatID int64, mode RepeatMode) {
+	update(r, chatID, func(c *ChatCacher) struct{} { c.SetRepeatMode(chatID, mode); return struct{}{} })
+}
+
+func (r *RedisStore) GetFairMode(chatID int64) bool {
+	return view(r, chatID, func(c *ChatCacher) bool { return c.GetFairMode(chatID) })
+}
+
+func (r *RedisStore) SetFairMode(chatID int64, enabled bool) {
+	update(r, chatID, func(c *ChatCacher) struct{} { c.SetFairMode(chatID, enabled); return struct{}{} })
+}
+
+func (r *RedisStore) GetLastYouTubeTrack(chatID int64) *utils.CachedTrack {
+	return view(r, chatID, func(c *ChatCacher) *utils.CachedTrack { return c.GetLastYouTubeTrack(chatID) })
+}
+
+func (r *RedisStore) ClearChat(chatID int64) {
+	update(r, chatID, func(c *ChatCacher) struct{} { c.ClearChat(chatID); return struct{}{} })
+}
+
+func (r *RedisStore) GetQueueLength(chatID int64) int {
+	return view(r, chatID, func(c *ChatCacher) int { return c.GetQueueLength(chatID) })
+}
+
+func (r *RedisStore) GetLoopCount(chatID int64) int {
+	return view(r, chatID, func(c *ChatCacher) int { return c.GetLoopCount(chatID) })
+}
+
+func (r *RedisStore) SetLoopCount(chatID int64, loop int) bool {
+	return update(r, chatID, func(c *ChatCacher) bool { return c.SetLoopCount(chatID, loop) })
+}
+
+func (r *RedisStore) GetQueue(chatID int64) []*utils.CachedTrack {
+	return view(r, chatID, func(c *ChatCacher) []*utils.CachedTrack { return c.GetQueue(chatID) })
+}
+
+func (r *RedisStore) SetTrackFile(chatID int64, trackID, filePath string) bool {
+	return update(r, chatID, func(c *ChatCacher) bool { return c.SetTrackFile(chatID, trackID, filePath) })
+}
+
+func (r *RedisStore) SetTrackDuration(chatID int64, trackID string, duration int) bool {
+	return update(r, chatID, func(c *ChatCacher) bool { return c.SetTrackDuration(chatID, trackID, duration) })
+}
+
+func (r *RedisStore) GetTrackIfExists(chatID int64, trackID string) *utils.CachedTrack {
+	return view(r, chatID, func(c *ChatCacher) *utils.CachedTrack { return c.GetTrackIfExists(chatID, trackID) })
+}
+
+func (r *RedisStore) FindDuplicate(chatID int64, track *utils.CachedTrack) *utils.CachedTrack {
+	return view(r, chatID, func(c *ChatCacher) *utils.CachedTrack { return c.FindDuplicate(chatID, track) })
+}
+
+func (r *RedisStore) Snapshot(chatID int64) *ChatSnapshot {
+	return view(r, chatID, func(c *ChatCacher) *ChatSnapshot { return c.Snapshot(chatID) })
+}
+
+// Restore replaces a chat's queue state with the contents of a snapshot without calling the change hooks.
+func (r *RedisStore) Restore(snap *ChatSnapshot) {
+	if snap == nil || len(snap.Queue) == 0 {
+		return
+	}
+	_, err := transact(r, snap.ChatID, func(c *ChatCacher) struct{} { c.Restore(snap); return struct{}{} })
+	if err != nil {
+		slog.Warn("[QueueStore] Failed to restore chat", "chatID", snap.ChatID, "error", err)
+	}
+}
+
+// GetActiveChats returns the IDs of all chats with at least one queued track.
+func (r *RedisStore) GetActiveChats() []int64 {
+	var active []int64
+	err := r.withConn(func(conn *respConn) error {
+		reply, err := conn.do("SMEMBERS", r.activeKey())
+		if err != nil {
+			return err
+		}
+		members, _ := reply.([]any)
+		for _, m := range members {
+			raw, _ := m.([]byte)
+			if id, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+				active = append(active, id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		slog.Warn("[QueueStore] Failed to list active chats", "error", err)
+	}
+	return active
+}
+
+// exportChat returns the full state of a chat, or nil if the cache holds nothing for it.
+// Unlike Snapshot it keeps chats whose queue is empty, so modes and history are not lost.
+func (c *ChatCacher) exportChat(chatID int64) *ChatSnapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	data, ok := c.chatCache[chatID]
+	ok = ok && (len(data.Queue) > 0 || data.Autoplay || data.Fair || data.LastYouTubeTrack != nil ||
+		(data.Repeat != "" && data.Repeat != RepeatOff))
+	history := c.history[chatID]
+	if !ok && len(history) == 0 {
+		return nil
+	}
+
+	snap := &ChatSnapshot{ChatID: chatID, History: history}
+	if ok {
+		snap.Queue = data.Queue
+		snap.Autoplay = data.Autoplay
+		snap.Repeat = data.Repeat
+		snap.Fair = data.Fair
+		snap.LastYouTubeTrack = data.LastYouTubeTrack
+	}
+	return snap
+}
+
+// importChat loads a state produced by exportChat. Unlike Restore it accepts an empty queue.
+func (c *ChatCacher) importChat(snap *ChatSnapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.chatCache[snap.ChatID] = &ChatData{
+		Queue:            snap.Queue,
+		Autoplay:         snap.Autoplay,
+		Repeat:           snap.Repeat,
+		Fair:             snap.Fair,
+		LastYouTubeTrack: snap.LastYouTubeTrack,
+	}
+	if len(snap.History) > 0 {
+		c.history[snap.ChatID] = snap.History
+	}
+}
@@ -0,0 +1,206 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package cache
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// respTimeout bounds dialing and every command sent to the server.
+const respTimeout = 5 * time.Second
+
+// respError is an error reply sent by the server.
+type respError string
+
+func (e respError) Error() string { return string(e) }
+
+// respOptions holds the connection settings parsed from a redis:// or rediss:// URL.
+type respOptions struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      bool
+}
+
+// parseRedisURL parses a URL of the form redis://[[user]:password@]host[:port][/db].
+func parseRedisURL(rawURL string) (respOptions, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return respOptions{}, fmt.Errorf("invalid redis url: %w", err)
+	}
+
+	var opts respOptions
+	switch u.Scheme {
+	case "redis":
+	case "rediss":
+		opts.tls = true
+	default:
+		return respOptions{}, fmt.Errorf("unsupported redis url scheme %q", u.Scheme)
+	}
+
+	opts.addr = u.Host
+	if u.Port() == "" {
+		opts.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		opts.username = u.User.Username()
+		opts.password, _ = u.User.Password()
+	}
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		if opts.db, err = strconv.Atoi(path); err != nil {
+			return respOptions{}, fmt.Errorf("invalid redis database %q", path)
+		}
+	}
+	return opts, nil
+}
+
+// respConn is a single connection speaking the Redis serialization protocol.
+type respConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+	// broken is set once a network error leaves the connection in an unknown state.
+	broken bool
+}
+
+// dialResp connects to the server, authenticates and selects the database.
+func dialResp(opts respOptions) (*respConn, error) {
+	dialer := &net.Dialer{Timeout: respTimeout}
+	var conn net.Conn
+	var err error
+	if opts.tls {
+		conn, err = tls.DialWithDialer(dialer, "tcp", opts.addr, nil)
+	} else {
+		conn, err = dialer.Dial("tcp", opts.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &respConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+	if opts.password != "" {
+		args := []string{"AUTH", opts.password}
+		if opts.username != "" {
+			args = []string{"AUTH", opts.username, opts.password}
+		}
+		if _, err = c.do(args...); err != nil {
+			c.close()
+			return nil, fmt.Errorf("redis auth failed: %w", err)
+		}
+	}
+	if opts.db != 0 {
+		if _, err = c.do("SELECT", strconv.Itoa(opts.db)); err != nil {
+			c.close()
+			return nil, fmt.Errorf("redis select failed: %w", err)
+		}
+	}
+	return c, nil
+}
+
+func (c *respConn) close() {
+	_ = c.conn.Close()
+}
+
+// do sends a command and returns its reply. Replies are decoded as string for simple
+// strings, int64 for integers, []byte or nil for bulk strings and []any or nil for arrays.
+// Error replies are returned as respError and leave the connection usable.
+func (c *respConn) do(args ...string) (any, error) {
+	_ = c.conn.SetDeadline(time.Now().Add(respTimeout))
+
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := c.w.Flush(); err != nil {
+		c.broken = true
+		return nil, err
+	}
+
+	reply, err := c.readReply()
+	if err != nil {
+		c.broken = true
+		return nil, err
+	}
+	if re, ok := reply.(respError); ok {
+		return nil, re
+	}
+	return reply, nil
+}
+
+func (c *respConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(line, "\r\n") {
+		return "", errors.New("malformed redis reply")
+	}
+	return line[:len(line)-2], nil
+}
+
+// readReply reads one reply. Error replies are returned as respError values rather than
+// errors, so that an error inside an array does not abort reading the rest of it.
+func (c *respConn) readReply() (any, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if line == "" {
+		return nil, errors.New("empty redis reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return respError(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]any, n)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unexpected redis reply %q", line)
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package cache
+
+import (
+	"ashokshau/tgmusic/src/utils"
+	"fmt"
+	"log/slog"
+)
+
+// QueueStore holds the per-chat playback queues, history and playback modes.
+// ChatCacher keeps them in memory; RedisStore keeps them on a Redis server so several
+// bot processes can serve the same chats.
+type QueueStore interface {
+	// OnChange registers a hook that is called after a chat's queue state is modified.
+	OnChange(hook func(chatID int64))
+
+	AddSong(chatID int64, song *utils.CachedTrack) int
+	AddSongs(chatID int64, songs []*utils.CachedTrack) int
+	AddSongToFront(chatID int64, song *utils.CachedTrack) int
+	AddSongsLimited(chatID int64, songs []*utils.CachedTrack, limits QueueLimits, front bool) (int, int, error)
+	MoveTrackToFront(chatID int64, trackID string) bool
+	GetPlayingTrack(chatID int64) *utils.CachedTrack
+	GetUpcomingTrack(chatID int64) *utils.CachedTrack
+	RemoveCurrentSong(chatID int64) *utils.CachedTrack
+	AdvanceQueue(chatID int64, skip bool) *utils.CachedTrack
+	GetHistory(chatID int64) []*utils.CachedTrack
+	PlayPrevious(chatID int64) *utils.CachedTrack
+	RemoveTrack(chatID int64, index int) bool
+	MoveTrack(chatID int64, from, to int) bool
+	ShuffleQueue(chatID int64) int
+	SkipTo(chatID int64, index int) bool
+	ClearQueue(chatID int64) int
+	IsActive(chatID int64) bool
+	GetAutoplay(chatID int64) bool
+	SetAutoplay(chatID int64, state bool)
+	GetRepeatMode(chatID int64) RepeatMode
+	SetRepeatMode(chatID int64, mode RepeatMode)
+	GetFairMode(chatID int64) bool
+	SetFairMode(chatID int64, enabled bool)
+	GetLastYouTubeTrack(chatID int64) *utils.CachedTrack
+	ClearChat(chatID int64)
+	GetQueueLength(chatID int64) int
+	GetLoopCount(chatID int64) int
+	SetLoopCount(chatID int64, loop int) bool
+	GetQueue(chatID int64) []*utils.CachedTrack
+	GetActiveChats() []int64
+	GetTrackIfExists(chatID int64, trackID string) *utils.CachedTrack
+	FindDuplicate(chatID int64, track *utils.CachedTrack) *utils.CachedTrack
+	SetTrackFile(chatID int64, trackID, filePath string) bool
+	SetTrackDuration(chatID int64, trackID string, duration int) bool
+	Snapshot(chatID int64) *ChatSnapshot
+	Restore(snap *ChatSnapshot)
+}
+
+var (
+	_ QueueStore = (*ChatCacher)(nil)
+	_ QueueStore = (*RedisStore)(nil)
+)
+
+// ChatDefaults holds the saved settings that a chat's queue state starts with.
+type ChatDefaults struct {
+	Fair   bool
+	Repeat RepeatMode
+}
+
+// LoadDefaults returns the saved settings of a chat when its queue state is created, so every
+// way of starting a queue honours them. It is set at startup, since the database package
+// depends on this one.
+var LoadDefaults func(chatID int64) ChatDefaults
+
+// ChatCache is the global queue store. It is in-memory unless InitQueueStore selects another one.
+var ChatCache QueueStore = newChatCacher()
+
+// InitQueueStore sets up the queue store of the given kind, "memory" or "redis".
+// It must run before any OnChange hooks are registered.
+func InitQueueStore(kind, redisURL string) error {
+	switch kind {
+	case "", "memory":
+		return nil
+	case "redis":
+		store, err := NewRedisStore(redisURL)
+		if err != nil {
+			return fmt.Errorf("failed to connect to redis: %w", err)
+		}
+		ChatCache = store
+		slog.Info("[QueueStore] Using the Redis queue store")
+		return nil
+	default:
+		return fmt.Errorf("unknown queue store %q", kind)
+	}
+}
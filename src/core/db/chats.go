@@ -9,9 +9,13 @@
 package db
 
 import (
+	"ashokshau/tgmusic/config"
+	"ashokshau/tgmusic/src/core/cache"
 	"ashokshau/tgmusic/src/utils"
 	"context"
+	"crypto/rand"
 	"errors"
+	"fmt"
 	"log/slog"
 	"time"
 
@@ -22,11 +26,27 @@ import (
 
 // Chats represents a chat document in the database.
 type Chats struct {
-	ID        int64  `bson:"_id"`
-	PlayType  int    `bson:"play_type"`
-	AdminPlay bool   `bson:"admin_play"`
-	AdminMode string `bson:"admin_mode"`
-	CmdDelete bool   `bson:"cmd_delete"`
+	ID                 int64       `bson:"_id"`
+	PlayType           int         `bson:"play_type"`
+	AdminPlay          bool        `bson:"admin_play"`
+	AdminMode          string      `bson:"admin_mode"`
+	CmdDelete          bool        `bson:"cmd_delete"`
+	QueueLimit         int         `bson:"queue_limit"`
+	UserQueueLimit     int         `bson:"user_queue_limit"`
+	QueueDurationLimit int         `bson:"queue_duration_limit"`
+	FairQueue          bool        `bson:"fair_queue"`
+	RepeatMode         string      `bson:"repeat_mode"`
+	DuplicateMode      string      `bson:"duplicate_mode"`
+	Effect             string      `bson:"effect"`
+	Speed              float64     `bson:"speed"`
+	Volume             int         `bson:"volume"`
+	Normalize          bool        `bson:"normalize"`
+	Quality            string      `bson:"quality"`
+	VideoMode          string      `bson:"video_mode"`
+	IngestKey          string      `bson:"ingest_key"`
+	Soundboard         []SoundClip `bson:"soundboard"`
+	AlwaysOn           bool        `bson:"always_on"`
+	AlwaysOnSeed       string      `bson:"always_on_seed"`
 }
 
 // getChat retrieves a chat's data from the cache or database.
@@ -167,6 +187,246 @@ func (db *Database) SetCmdDelete(chatID int64, cmdDelete bool) error {
 	return err
 }
 
+// updateChat applies a $set update to a chat document and invalidates the cached copy.
+func (db *Database) updateChat(chatID int64, fields bson.M) error {
+	ctx, cancel := db.ctx()
+	defer cancel()
+
+	_, err := db.chatDB.UpdateOne(ctx, bson.M{"_id": chatID}, bson.M{"$set": fields}, options.UpdateOne().SetUpsert(true))
+	if err == nil {
+		db.chatCache.Delete(toKey(chatID))
+	}
+	return err
+}
+
+// GetQueueLimit retrieves the maximum number of queued tracks for a chat.
+func (db *Database) GetQueueLimit(chatID int64) int {
+	chat, _ := db.getChat(chatID)
+	if chat == nil || chat.QueueLimit <= 0 {
+		return int(config.QueueLimit)
+	}
+	return chat.QueueLimit
+}
+
+// SetQueueLimit sets the maximum number of queued tracks for a chat.
+func (db *Database) SetQueueLimit(chatID int64, limit int) error {
+	return db.updateChat(chatID, bson.M{"queue_limit": limit})
+}
+
+// GetUserQueueLimit retrieves the maximum number of tracks a single user may have queued.
+// A value of 0 means there is no limit.
+func (db *Database) GetUserQueueLimit(chatID int64) int {
+	chat, _ := db.getChat(chatID)
+	if chat == nil {
+		return 0
+	}
+	return chat.UserQueueLimit
+}
+
+// SetUserQueueLimit sets the maximum number of tracks a single user may have queued.
+func (db *Database) SetUserQueueLimit(chatID int64, limit int) error {
+	return db.updateChat(chatID, bson.M{"user_queue_limit": limit})
+}
+
+// GetQueueDurationLimit retrieves the maximum total duration of the queue in seconds.
+// A value of 0 means there is no limit.
+func (db *Database) GetQueueDurationLimit(chatID int64) int {
+	chat, _ := db.getChat(chatID)
+	if chat == nil {
+		return 0
+	}
+	return chat.QueueDurationLimit
+}
+
+// SetQueueDurationLimit sets the maximum total duration of the queue in seconds.
+func (db *Database) SetQueueDurationLimit(chatID int64, seconds int) error {
+	return db.updateChat(chatID, bson.M{"queue_duration_limit": seconds})
+}
+
+// GetFairQueue reports whether a chat interleaves queued tracks by requester.
+func (db *Database) GetFairQueue(chatID int64) bool {
+	chat, _ := db.getChat(chatID)
+	if chat == nil {
+		return false
+	}
+	return chat.FairQueue
+}
+
+// SetFairQueue sets whether a chat interleaves queued tracks by requester.
+func (db *Database) SetFairQueue(chatID int64, fair bool) error {
+	return db.updateChat(chatID, bson.M{"fair_queue": fair})
+}
+
+// GetRepeatMode retrieves what happens to a chat's finished tracks. It defaults to removing them.
+func (db *Database) GetRepeatMode(chatID int64) cache.RepeatMode {
+	chat, _ := db.getChat(chatID)
+	if chat == nil || chat.RepeatMode == "" {
+		return cache.RepeatOff
+	}
+	return cache.RepeatMode(chat.RepeatMode)
+}
+
+// SetRepeatMode sets what happens to a chat's finished tracks.
+func (db *Database) SetRepeatMode(chatID int64, mode cache.RepeatMode) error {
+	return db.updateChat(chatID, bson.M{"repeat_mode": string(mode)})
+}
+
+// GetDuplicateMode retrieves how a chat handles songs that are already queued.
+// It defaults to rejecting them.
+func (db *Database) GetDuplicateMode(chatID int64) string {
+	chat, _ := db.getChat(chatID)
+	if chat == nil || chat.DuplicateMode == "" {
+		return utils.DuplicateReject
+	}
+	return chat.DuplicateMode
+}
+
+// SetDuplicateMode sets how a chat handles songs that are already queued.
+func (db *Database) SetDuplicateMode(chatID int64, mode string) error {
+	return db.updateChat(chatID, bson.M{"duplicate_mode": mode})
+}
+
+// GetEffect retrieves the audio effect preset applied to a chat's streams, or "" for none.
+func (db *Database) GetEffect(chatID int64) string {
+	chat, _ := db.getChat(chatID)
+	if chat == nil {
+		return ""
+	}
+	return chat.Effect
+}
+
+// SetEffect sets the audio effect preset applied to a chat's streams.
+func (db *Database) SetEffect(chatID int64, effect string) error {
+	return db.updateChat(chatID, bson.M{"effect": effect})
+}
+
+// GetSpeed retrieves the playback speed of a chat's streams. It defaults to 1.
+func (db *Database) GetSpeed(chatID int64) float64 {
+	chat, _ := db.getChat(chatID)
+	if chat == nil || chat.Speed <= 0 {
+		return 1
+	}
+	return chat.Speed
+}
+
+// SetSpeed sets the playback speed of a chat's streams.
+func (db *Database) SetSpeed(chatID int64, speed float64) error {
+	return db.updateChat(chatID, bson.M{"speed": speed})
+}
+
+// GetVolume retrieves the volume level of a chat's streams in percent. It defaults to 100.
+func (db *Database) GetVolume(chatID int64) int {
+	chat, _ := db.getChat(chatID)
+	if chat == nil || chat.Volume <= 0 {
+		return 100
+	}
+	return chat.Volume
+}
+
+// SetVolume sets the volume level of a chat's streams in percent.
+func (db *Database) SetVolume(chatID int64, volume int) error {
+	return db.updateChat(chatID, bson.M{"volume": volume})
+}
+
+// GetNormalize returns whether loudness normalization is enabled for a chat.
+func (db *Database) GetNormalize(chatID int64) bool {
+	chat, _ := db.getChat(chatID)
+	if chat == nil {
+		return false
+	}
+	return chat.Normalize
+}
+
+// SetNormalize enables or disables loudness normalization for a chat.
+func (db *Database) SetNormalize(chatID int64, enabled bool) error {
+	return db.updateChat(chatID, bson.M{"normalize": enabled})
+}
+
+// GetQuality retrieves the name of a chat's quality profile. It defaults to the DEFAULT_QUALITY setting.
+func (db *Database) GetQuality(chatID int64) string {
+	chat, _ := db.getChat(chatID)
+	if chat == nil || chat.Quality == "" {
+		return config.DefaultQuality
+	}
+	return chat.Quality
+}
+
+// SetQuality sets the name of a chat's quality profile.
+func (db *Database) SetQuality(chatID int64, quality string) error {
+	return db.updateChat(chatID, bson.M{"quality": quality})
+}
+
+// GetVideoMode retrieves how video tracks are shown in a chat. It defaults to camera.
+func (db *Database) GetVideoMode(chatID int64) string {
+	chat, _ := db.getChat(chatID)
+	if chat == nil || chat.VideoMode == "" {
+		return utils.VideoModeCamera
+	}
+	return chat.VideoMode
+}
+
+// SetVideoMode sets how video tracks are shown in a chat.
+func (db *Database) SetVideoMode(chatID int64, mode string) error {
+	return db.updateChat(chatID, bson.M{"video_mode": mode})
+}
+
+// GetIngestKey retrieves the stream key that authorizes live ingest into a chat, or "" if none is set.
+func (db *Database) GetIngestKey(chatID int64) string {
+	chat, _ := db.getChat(chatID)
+	if chat == nil {
+		return ""
+	}
+	return chat.IngestKey
+}
+
+// NewIngestKey generates and stores a new stream key for live ingest into a chat, replacing
+// the previous one.
+func (db *Database) NewIngestKey(chatID int64) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("%x", b)
+	return key, db.updateChat(chatID, bson.M{"ingest_key": key})
+}
+
+// GetAlwaysOn reports whether a chat keeps playing around the clock, and returns the seed
+// it refills the queue from: a saved playlist ID or a YouTube track ID for autoplay.
+func (db *Database) GetAlwaysOn(chatID int64) (bool, string) {
+	chat, _ := db.getChat(chatID)
+	if chat == nil {
+		return false, ""
+	}
+	return chat.AlwaysOn, chat.AlwaysOnSeed
+}
+
+// SetAlwaysOn enables or disables around-the-clock playback for a chat with the given seed.
+func (db *Database) SetAlwaysOn(chatID int64, enabled bool, seed string) error {
+	return db.updateChat(chatID, bson.M{"always_on": enabled, "always_on_seed": seed})
+}
+
+// GetAlwaysOnChats retrieves the IDs of the chats that keep playing around the clock.
+func (db *Database) GetAlwaysOnChats() ([]int64, error) {
+	ctx, cancel := db.ctx()
+	defer cancel()
+
+	cursor, err := db.chatDB.Find(ctx, bson.M{"always_on": true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = cursor.Close(ctx) }()
+
+	var chats []int64
+	for cursor.Next(ctx) {
+		var doc Chats
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		chats = append(chats, doc.ID)
+	}
+	return chats, cursor.Err()
+}
+
 // GetAllChats retrieves a list of all chat IDs from the database.
 func (db *Database) GetAllChats() ([]int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
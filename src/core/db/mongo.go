@@ -33,6 +33,7 @@ type Database struct {
 	authDB      *mongo.Collection
 	langDB      *mongo.Collection
 	cacheDB     *mongo.Collection
+	queueDB     *mongo.Collection
 
 	chatCache      *cache.Cache[*Chats]
 	userCache      *cache.Cache[*Users]
@@ -73,6 +74,7 @@ func InitDatabase() error {
 		authDB:      db.Collection("auth"),
 		langDB:      db.Collection("lang"),
 		cacheDB:     db.Collection("cache"),
+		queueDB:     db.Collection("queues"),
 
 		chatCache:      cache.NewCache[*Chats](60 * time.Minute),
 		userCache:      cache.NewCache[*Users](60 * time.Minute),
@@ -0,0 +1,56 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package db
+
+import (
+	"ashokshau/tgmusic/src/core/cache"
+	"context"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// SaveQueue stores a snapshot of a chat's queue, replacing any previous one.
+func (db *Database) SaveQueue(snap *cache.ChatSnapshot) error {
+	ctx, cancel := db.ctx()
+	defer cancel()
+
+	_, err := db.queueDB.ReplaceOne(ctx, bson.M{"_id": snap.ChatID}, snap, options.Replace().SetUpsert(true))
+	return err
+}
+
+// DeleteQueue removes the saved queue of a chat.
+func (db *Database) DeleteQueue(chatID int64) error {
+	ctx, cancel := db.ctx()
+	defer cancel()
+
+	_, err := db.queueDB.DeleteOne(ctx, bson.M{"_id": chatID})
+	return err
+}
+
+// GetSavedQueues returns all saved queue snapshots.
+func (db *Database) GetSavedQueues() ([]*cache.ChatSnapshot, error) {
+	ctx, cancel := db.ctx()
+	defer cancel()
+
+	cursor, err := db.queueDB.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		_ = cursor.Close(ctx)
+	}(cursor, ctx)
+
+	var snaps []*cache.ChatSnapshot
+	if err = cursor.All(ctx, &snaps); err != nil {
+		return nil, err
+	}
+	return snaps, nil
+}
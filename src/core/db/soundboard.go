@@ -0,0 +1,64 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package db
+
+import (
+	"slices"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// SoundClip is a short audio clip saved to a chat's soundboard.
+type SoundClip struct {
+	Name string `bson:"name"`
+	// FileID is the Telegram remote file ID of the clip's audio.
+	FileID   string `bson:"file_id"`
+	Duration int    `bson:"duration"`
+	AddedBy  int64  `bson:"added_by"`
+}
+
+// GetSoundClips retrieves the clips on a chat's soundboard in the order they were added.
+func (db *Database) GetSoundClips(chatID int64) []SoundClip {
+	chat, _ := db.getChat(chatID)
+	if chat == nil {
+		return nil
+	}
+	return chat.Soundboard
+}
+
+// GetSoundClip retrieves a clip from a chat's soundboard by name, or nil if there is none.
+func (db *Database) GetSoundClip(chatID int64, name string) *SoundClip {
+	for _, clip := range db.GetSoundClips(chatID) {
+		if clip.Name == name {
+			return &clip
+		}
+	}
+	return nil
+}
+
+// SetSoundClip saves a clip to a chat's soundboard, replacing a clip with the same name.
+func (db *Database) SetSoundClip(chatID int64, clip SoundClip) error {
+	clips := slices.Clone(db.GetSoundClips(chatID))
+	if i := slices.IndexFunc(clips, func(c SoundClip) bool { return c.Name == clip.Name }); i >= 0 {
+		clips[i] = clip
+	} else {
+		clips = append(clips, clip)
+	}
+	return db.updateChat(chatID, bson.M{"soundboard": clips})
+}
+
+// RemoveSoundClip removes a clip from a chat's soundboard by name. It reports whether the clip existed.
+func (db *Database) RemoveSoundClip(chatID int64, name string) (bool, error) {
+	clips := db.GetSoundClips(chatID)
+	i := slices.IndexFunc(clips, func(c SoundClip) bool { return c.Name == name })
+	if i < 0 {
+		return false, nil
+	}
+	return true, db.updateChat(chatID, bson.M{"soundboard": slices.Delete(slices.Clone(clips), i, i+1)})
+}
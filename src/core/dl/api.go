@@ -13,6 +13,7 @@ import (
 
 	"ashokshau/tgmusic/config"
 	"ashokshau/tgmusic/src/utils"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -161,11 +162,11 @@ func (a *apiData) getTrack() (utils.TrackInfo, error) {
 }
 
 // downloadTrack downloads a track using the API. If the track is a YouTube video and video format is requested,
-func (a *apiData) downloadTrack(info utils.TrackInfo, video bool) (string, error) {
+func (a *apiData) downloadTrack(ctx context.Context, info utils.TrackInfo, video bool) (string, error) {
 	// if the track is from YouTube and video:true
 	yt := newYouTubeData(a.Query)
 	if info.Platform == utils.YouTube && video {
-		return yt.downloadTrack(info, video)
+		return yt.downloadTrack(ctx, info, video)
 	}
 
 	downloader, err := newDownload(info)
@@ -173,16 +174,16 @@ func (a *apiData) downloadTrack(info utils.TrackInfo, video bool) (string, error
 		return "", fmt.Errorf("failed to initialize the download: %w", err)
 	}
 
-	filePath, err := downloader.Process()
+	filePath, err := downloader.Process(ctx)
 	if err != nil {
-		if info.Platform == utils.YouTube {
-			return yt.downloadTrack(info, video)
+		if info.Platform == utils.YouTube && ctx.Err() == nil {
+			return yt.downloadTrack(ctx, info, video)
 		}
 		return "", fmt.Errorf("the download process failed: %w", err)
 	}
 
 	if strings.Contains(a.ApiUrl, filePath) {
-		return downloadFile(filePath, "", false)
+		return downloadFile(ctx, filePath, "", false)
 	}
 
 	return filePath, nil
@@ -92,6 +92,8 @@ func (d *directLink) getInfo() (utils.PlatformTracks, error) {
 		Url:      d.query,
 		Id:       d.query,
 		Platform: utils.DirectLink,
+		// ffprobe reports no duration for endless streams such as radio stations and live HLS.
+		IsLive: duration == 0,
 	}
 
 	return utils.PlatformTracks{Results: []utils.MusicTrack{track}}, nil
@@ -120,6 +122,6 @@ func (d *directLink) getTrack() (utils.TrackInfo, error) {
 	}, nil
 }
 
-func (d *directLink) downloadTrack(_ utils.TrackInfo, _ bool) (string, error) {
+func (d *directLink) downloadTrack(_ context.Context, _ utils.TrackInfo, _ bool) (string, error) {
 	return d.query, nil
 }
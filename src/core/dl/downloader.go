@@ -10,12 +10,20 @@ package dl
 
 import (
 	"ashokshau/tgmusic/src/utils"
+	"context"
 	"fmt"
 
 	td "github.com/AshokShau/gotdbot"
 )
 
-func DownloadCachedTrack(cached *utils.CachedTrack, bot *td.Client) (string, error) {
+// DownloadCachedTrack downloads a queued track and returns the file or URL to play.
+// Cancelling ctx stops the download, except for Telegram files, which TDLib finishes in the
+// background and keeps for the next request.
+func DownloadCachedTrack(ctx context.Context, cached *utils.CachedTrack, bot *td.Client) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
 	if cached.Platform == utils.DirectLink {
 		return cached.URL, nil
 	}
@@ -29,10 +37,10 @@ func DownloadCachedTrack(cached *utils.CachedTrack, bot *td.Client) (string, err
 		dlBot = DlBot
 	}
 
-	return downloadViaWrapper(cached, dlBot)
+	return downloadViaWrapper(ctx, cached, dlBot)
 }
 
-func downloadViaWrapper(cached *utils.CachedTrack, dlBot *td.Client) (string, error) {
+func downloadViaWrapper(ctx context.Context, cached *utils.CachedTrack, dlBot *td.Client) (string, error) {
 	wrapper := NewDownloaderWrapper(cached.URL)
 	if !wrapper.IsValid() {
 		return "", fmt.Errorf("invalid cached URL: %s", cached.URL)
@@ -43,7 +51,8 @@ func downloadViaWrapper(cached *utils.CachedTrack, dlBot *td.Client) (string, er
 		return "", fmt.Errorf("get track info: %w", err)
 	}
 
-	path, err := wrapper.DownloadTrack(track, cached.IsVideo)
+	track.Quality = cached.Quality
+	path, err := wrapper.DownloadTrack(ctx, track, cached.IsVideo)
 	if err != nil {
 		return "", err
 	}
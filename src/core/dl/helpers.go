@@ -10,6 +10,7 @@ package dl
 
 import (
 	"ashokshau/tgmusic/src/utils"
+	"context"
 	"errors"
 	"net/url"
 	"regexp"
@@ -41,13 +42,13 @@ func newDownload(track utils.TrackInfo) (*download, error) {
 }
 
 // Process initiates the download process based on the track's platform.
-func (d *download) Process() (string, error) {
+func (d *download) Process(ctx context.Context) (string, error) {
 	switch {
 	case d.Track.CdnURL == "":
 		return "", errMissingCDNURL
 
 	case d.Track.Key != "" && strings.EqualFold(d.Track.Platform, "spotify"):
-		return d.processSpotify()
+		return d.processSpotify(ctx)
 
 	default:
 		return d.processDirectDL()
@@ -183,12 +183,12 @@ func writeToFile(filename string, data io.Reader) error {
 }
 
 // downloadFile downloads a file from a URL and saves it to a local path.
-func downloadFile(urlStr, fileName string, overwrite bool) (string, error) {
+func downloadFile(ctx context.Context, urlStr, fileName string, overwrite bool) (string, error) {
 	if urlStr == "" {
 		return "", errors.New("an empty URL was provided")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
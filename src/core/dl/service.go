@@ -11,6 +11,7 @@ package dl
 import (
 	"ashokshau/tgmusic/config"
 	"ashokshau/tgmusic/src/utils"
+	"context"
 )
 
 // musicService defines a standard interface for interacting with various music services.
@@ -24,8 +25,8 @@ type musicService interface {
 	search() (utils.PlatformTracks, error)
 	// getTrack fetches detailed information for a single track.
 	getTrack() (utils.TrackInfo, error)
-	// downloadTrack handles the download of a track.
-	downloadTrack(trackInfo utils.TrackInfo, video bool) (string, error)
+	// downloadTrack handles the download of a track. Cancelling ctx stops the download.
+	downloadTrack(ctx context.Context, trackInfo utils.TrackInfo, video bool) (string, error)
 }
 
 // DownloaderWrapper provides a unified interface for music service interactions.
@@ -82,6 +83,6 @@ func (d *DownloaderWrapper) GetTrack() (utils.TrackInfo, error) {
 
 // DownloadTrack downloads a track by delegating the call to the wrapped service.
 // It returns the file path of the downloaded track or an error if the download fails.
-func (d *DownloaderWrapper) DownloadTrack(info utils.TrackInfo, video bool) (string, error) {
-	return d.service.downloadTrack(info, video)
+func (d *DownloaderWrapper) DownloadTrack(ctx context.Context, info utils.TrackInfo, video bool) (string, error) {
+	return d.service.downloadTrack(ctx, info, video)
 }
@@ -38,7 +38,7 @@ var (
 )
 
 // processSpotify manages the download and decryption of Spotify tracks.
-func (d *download) processSpotify() (string, error) {
+func (d *download) processSpotify(ctx context.Context) (string, error) {
 	track := d.Track
 	downloadsDir := config.DownloadsDir
 	sanitizedTrackID := filepath.Base(track.Id)
@@ -66,7 +66,7 @@ func (d *download) processSpotify() (string, error) {
 		_ = os.Remove(decryptedFile)
 	}()
 
-	if err := d.downloadAndDecrypt(encryptedFile, decryptedFile); err != nil {
+	if err := d.downloadAndDecrypt(ctx, encryptedFile, decryptedFile); err != nil {
 		slog.Info("Failed to download and decrypt the file", "error", err)
 		return "", err
 	}
@@ -75,12 +75,16 @@ func (d *download) processSpotify() (string, error) {
 		slog.Info("Failed to rebuild the OGG headers", "error", err)
 	}
 
-	return fixOGG(decryptedFile, track)
+	return fixOGG(ctx, decryptedFile, track)
 }
 
 // downloadAndDecrypt handles the download and decryption of a file.
-func (d *download) downloadAndDecrypt(encryptedPath, decryptedPath string) error {
-	resp, err := http.Get(d.Track.CdnURL)
+func (d *download) downloadAndDecrypt(ctx context.Context, encryptedPath, decryptedPath string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.Track.CdnURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create the request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to download the file: %w", err)
 	}
@@ -182,8 +186,8 @@ func rebuildOGG(filename string) error {
 }
 
 // fixOGG uses ffmpeg to correct any remaining issues in the OGG file, ensuring it is playable.
-func fixOGG(inputFile string, track utils.TrackInfo) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+func fixOGG(ctx context.Context, inputFile string, track utils.TrackInfo) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
 	defer cancel()
 
 	sanitizedTrackID := filepath.Base(track.Id)
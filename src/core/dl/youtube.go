@@ -163,23 +163,30 @@ func (y *youTubeData) getTrack() (utils.TrackInfo, error) {
 }
 
 // downloadTrack handles the download of a track from YouTube.
-func (y *youTubeData) downloadTrack(info utils.TrackInfo, video bool) (string, error) {
+func (y *youTubeData) downloadTrack(ctx context.Context, info utils.TrackInfo, video bool) (string, error) {
 	if !video && info.CdnURL != "" {
 		return info.CdnURL, nil
 	}
 
 	if !video && y.ApiUrl != "" && y.APIKey != "" {
-		if filePath, err := y.downloadWithApi(info.Id, video); err == nil {
+		if filePath, err := y.downloadWithApi(ctx, info.Id, video); err == nil {
 			return filePath, nil
 		}
 	}
 
-	return y.downloadWithYtDlp(info.Id, video)
+	return y.downloadWithYtDlp(ctx, info.Id, video, info.Quality)
 }
 
-// buildYtdlpParams constructs the command-line parameters for yt-dlp to download media.
-func (y *youTubeData) buildYtdlpParams(videoID string, video bool) ([]string, string) {
-	outputTemplate := filepath.Join(config.DownloadsDir, "%(id)s.%(ext)s")
+// buildYtdlpParams constructs the command-line parameters for yt-dlp to download media
+// in the given quality profile.
+func (y *youTubeData) buildYtdlpParams(videoID string, video bool, quality string) ([]string, string) {
+	profile := utils.GetQualityProfile(quality)
+	// Files of other profiles get a suffix so they are not reused in place of each other.
+	outputName := "%(id)s.%(ext)s"
+	if quality != "" && quality != utils.QualityStandard {
+		outputName = "%(id)s_" + quality + ".%(ext)s"
+	}
+	outputTemplate := filepath.Join(config.DownloadsDir, outputName)
 	var cookieFile string
 
 	params := []string{
@@ -204,10 +211,9 @@ func (y *youTubeData) buildYtdlpParams(videoID string, video bool) ([]string, st
 	}
 
 	if video {
-		formatSelector := "bestvideo[height<=720]+bestaudio/best[height<=720]"
-		params = append(params, "-f", formatSelector, "--merge-output-format", "mp4")
+		params = append(params, "-f", profile.VideoFormat, "--merge-output-format", "mp4")
 	} else {
-		params = append(params, "-f", "bestaudio[ext=m4a]/bestaudio")
+		params = append(params, "-f", profile.AudioFormat)
 	}
 
 	cookieFile = y.getCookieFile()
@@ -224,14 +230,14 @@ func (y *youTubeData) buildYtdlpParams(videoID string, video bool) ([]string, st
 }
 
 // downloadWithYtDlp downloads media from YouTube using the yt-dlp command-line tool.
-func (y *youTubeData) downloadWithYtDlp(videoID string, video bool) (string, error) {
+func (y *youTubeData) downloadWithYtDlp(ctx context.Context, videoID string, video bool, quality string) (string, error) {
 	if videoID == "" {
 		return "", errors.New("videoID is empty")
 	}
 
-	ytdlpParams, cookieFile := y.buildYtdlpParams(videoID, video)
+	ytdlpParams, cookieFile := y.buildYtdlpParams(videoID, video, quality)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, ytdlpParams[0], ytdlpParams[1:]...)
@@ -250,6 +256,9 @@ func (y *youTubeData) downloadWithYtDlp(videoID string, video bool) (string, err
 		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
 			return "", fmt.Errorf("yt-dlp timed out for video ID: %s", videoID)
 		}
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("yt-dlp was cancelled for video ID %s: %w", videoID, ctx.Err())
+		}
 
 		return "", fmt.Errorf("an unexpected error occurred while downloading %s: %w", videoID, err)
 	}
@@ -282,7 +291,7 @@ func (y *youTubeData) getCookieFile() string {
 }
 
 // downloadWithApi downloads a track using the external API.
-func (y *youTubeData) downloadWithApi(videoID string, _ bool) (string, error) {
+func (y *youTubeData) downloadWithApi(ctx context.Context, videoID string, _ bool) (string, error) {
 	videoUrl := fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID)
 	api := newApiData(videoUrl)
 	track, err := api.getTrack()
@@ -296,5 +305,5 @@ func (y *youTubeData) downloadWithApi(videoID string, _ bool) (string, error) {
 		return "", err
 	}
 
-	return down.Process()
+	return down.Process(ctx)
 }
@@ -386,6 +386,16 @@ func normalizeYouTubeURL(rawURL string) string {
 	return ytWatchURL + id
 }
 
+// CanonicalURL returns a stable form of a track URL, so that the same YouTube video
+// linked as youtu.be, shorts or a watch URL with extra parameters compares equal.
+func CanonicalURL(rawURL string) string {
+	u := normalizeYouTubeURL(rawURL)
+	if m := videoIDRe1.FindStringSubmatch(u); len(m) > 1 {
+		return ytWatchURL + m[1]
+	}
+	return u
+}
+
 // extractSegment splits on sep, then strips query string and fragment.
 func extractSegment(u, sep string) string {
 	after := strings.SplitN(u, sep, 2)[1]
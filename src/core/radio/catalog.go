@@ -0,0 +1,175 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package radio
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPlaylistSize bounds how much of a remote playlist is read.
+const maxPlaylistSize = 1 << 20
+
+//go:embed stations.m3u
+var bundledCatalog string
+
+// client fetches playlists and stream metadata. It has no overall timeout because metadata
+// is read from endless streams; requests are bounded by their context instead.
+var client = &http.Client{
+	Transport: &http.Transport{
+		ResponseHeaderTimeout: 15 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// Station is an entry of the radio catalog.
+type Station struct {
+	Name string
+	URL  string
+}
+
+// LoadCatalog reads the station catalog from an M3U or PLS file, or returns the bundled
+// catalog when path is empty.
+func LoadCatalog(path string) ([]Station, error) {
+	data := bundledCatalog
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the radio catalog: %w", err)
+		}
+		data = string(b)
+	}
+
+	stations := ParsePlaylist(data)
+	if len(stations) == 0 {
+		return nil, errors.New("the radio catalog has no stations")
+	}
+	return stations, nil
+}
+
+// ParsePlaylist parses an M3U or PLS playlist. Entries without a title are named after their URL.
+func ParsePlaylist(data string) []Station {
+	data = strings.TrimPrefix(data, "\ufeff")
+	if strings.HasPrefix(strings.TrimSpace(data), "[playlist]") {
+		return parsePLS(data)
+	}
+	return parseM3U(data)
+}
+
+func parseM3U(data string) []Station {
+	var stations []Station
+	var title string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			// #EXTINF:<duration> [attributes],<title>
+			if _, t, ok := strings.Cut(line, ","); ok {
+				title = strings.TrimSpace(t)
+			}
+		case strings.HasPrefix(line, "#"):
+		default:
+			stations = append(stations, newStation(title, line))
+			title = ""
+		}
+	}
+	return stations
+}
+
+func parsePLS(data string) []Station {
+	files := make(map[int]string)
+	titles := make(map[int]string)
+	var order []int
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch {
+		case strings.HasPrefix(key, "file"):
+			if n, err := strconv.Atoi(key[len("file"):]); err == nil {
+				if _, seen := files[n]; !seen {
+					order = append(order, n)
+				}
+				files[n] = value
+			}
+		case strings.HasPrefix(key, "title"):
+			if n, err := strconv.Atoi(key[len("title"):]); err == nil {
+				titles[n] = value
+			}
+		}
+	}
+
+	stations := make([]Station, 0, len(order))
+	for _, n := range order {
+		stations = append(stations, newStation(titles[n], files[n]))
+	}
+	return stations
+}
+
+func newStation(name, url string) Station {
+	if name == "" {
+		name = url
+	}
+	return Station{Name: name, URL: url}
+}
+
+// isPlaylistURL reports whether a URL points to an M3U or PLS playlist of streams rather than
+// a stream. HLS playlists (.m3u8) are streams ffmpeg plays directly.
+func isPlaylistURL(url string) bool {
+	path := strings.ToLower(strings.SplitN(strings.SplitN(url, "?", 2)[0], "#", 2)[0])
+	return strings.HasSuffix(path, ".m3u") || strings.HasSuffix(path, ".pls")
+}
+
+// ResolveStream returns the stream to play for a station URL. Links to M3U and PLS playlists,
+// which many stations publish instead of the stream itself, are replaced with their first entry.
+func ResolveStream(ctx context.Context, url string) (string, error) {
+	if !isPlaylistURL(url) {
+		return url, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the station playlist: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch the station playlist: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPlaylistSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the station playlist: %w", err)
+	}
+	for _, s := range ParsePlaylist(string(body)) {
+		if strings.HasPrefix(s.URL, "http://") || strings.HasPrefix(s.URL, "https://") {
+			return s.URL, nil
+		}
+	}
+	return "", errors.New("the station playlist has no streams")
+}
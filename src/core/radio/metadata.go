@@ -0,0 +1,226 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package radio
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxReconnectDelay caps the wait between attempts to reopen a stream for its metadata.
+const maxReconnectDelay = time.Minute
+
+// errNoMetadata means the stream does not publish what is playing.
+var errNoMetadata = errors.New("stream has no metadata")
+
+// WatchMetadata follows the now-playing title of a live stream until ctx is done, calling
+// onChange whenever it changes. Icecast/Shoutcast streams are read for their ICY metadata and
+// HLS playlists are polled for segment titles. Streams without metadata are left alone.
+// An ICY stream is read over its own connection next to the one playing it, so watching its
+// titles takes as much bandwidth again as the stream itself.
+func WatchMetadata(ctx context.Context, streamURL string, onChange func(title string)) {
+	var last string
+	report := func(title string) {
+		title = strings.TrimSpace(title)
+		if title != "" && title != last {
+			last = title
+			onChange(title)
+		}
+	}
+
+	delay := time.Second
+	for {
+		err := watchOnce(ctx, streamURL, report)
+		if ctx.Err() != nil || errors.Is(err, errNoMetadata) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// watchOnce opens the stream and reports its titles until the connection ends.
+func watchOnce(ctx context.Context, streamURL string, report func(string)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Icy-MetaData", "1")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	if metaInt, err := strconv.Atoi(resp.Header.Get("icy-metaint")); err == nil && metaInt > 0 {
+		return readICY(resp.Body, metaInt, report)
+	}
+	if isHLS(resp) {
+		_ = resp.Body.Close()
+		return pollHLS(ctx, resp.Request.URL, report)
+	}
+	return errNoMetadata
+}
+
+// readICY reads the metadata blocks interleaved with the audio of an ICY stream: after every
+// metaInt bytes of audio comes one length byte, counting 16-byte units, and the metadata.
+func readICY(body io.Reader, metaInt int, report func(string)) error {
+	r := bufio.NewReader(body)
+	for {
+		if _, err := r.Discard(metaInt); err != nil {
+			return err
+		}
+		size, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if size == 0 {
+			continue
+		}
+
+		block := make([]byte, int(size)*16)
+		if _, err = io.ReadFull(r, block); err != nil {
+			return err
+		}
+		if title, ok := parseStreamTitle(string(block)); ok {
+			report(title)
+		}
+	}
+}
+
+// parseStreamTitle extracts StreamTitle from an ICY metadata block such as
+// StreamTitle='Artist - Title';StreamUrl='https://example.com';
+func parseStreamTitle(block string) (string, bool) {
+	const key = "StreamTitle='"
+	start := strings.Index(block, key)
+	if start < 0 {
+		return "", false
+	}
+	rest := block[start+len(key):]
+	// Titles may contain quotes, so the value ends at the quote before the field separator.
+	end := strings.Index(rest, "';")
+	if end < 0 {
+		end = strings.LastIndex(strings.TrimRight(rest, "\x00"), "'")
+	}
+	if end < 0 {
+		return "", false
+	}
+	return rest[:end], true
+}
+
+func isHLS(resp *http.Response) bool {
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	return strings.Contains(contentType, "mpegurl") || strings.HasSuffix(strings.ToLower(resp.Request.URL.Path), ".m3u8")
+}
+
+// pollHLS reloads an HLS playlist and reports the title of its newest segment. A master
+// playlist is followed to its first variant. Playlists whose segments carry no titles are
+// not polled further.
+func pollHLS(ctx context.Context, playlistURL *url.URL, report func(string)) error {
+	for polled := false; ; polled = true {
+		playlist, err := fetchPlaylist(ctx, playlistURL.String())
+		if err != nil {
+			return err
+		}
+
+		if variant := firstVariant(playlist); variant != "" {
+			ref, err := url.Parse(variant)
+			if err != nil {
+				return err
+			}
+			playlistURL = playlistURL.ResolveReference(ref)
+			polled = false
+			continue
+		}
+
+		title, interval := hlsNowPlaying(playlist)
+		if (title == "" && !polled) || interval == 0 {
+			return errNoMetadata
+		}
+		report(title)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func fetchPlaylist(ctx context.Context, playlistURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, playlistURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPlaylistSize))
+	return string(body), err
+}
+
+// firstVariant returns the URI of the first variant stream of a master playlist, or "" for a
+// media playlist.
+func firstVariant(playlist string) string {
+	lines := strings.Split(playlist, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#EXT-X-STREAM-INF") {
+			continue
+		}
+		for _, next := range lines[i+1:] {
+			if next = strings.TrimSpace(next); next != "" && !strings.HasPrefix(next, "#") {
+				return next
+			}
+		}
+	}
+	return ""
+}
+
+// hlsNowPlaying returns the title of the newest segment of a media playlist, and the target
+// duration the playlist should be reloaded after, or 0 when it sets none.
+func hlsNowPlaying(playlist string) (string, time.Duration) {
+	var title string
+	var interval time.Duration
+	for _, line := range strings.Split(playlist, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			if n, err := strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:")); err == nil && n > 0 {
+				interval = time.Duration(n) * time.Second
+			}
+		case strings.HasPrefix(line, "#EXTINF:"):
+			// #EXTINF:<duration>,<title>; the title may be empty.
+			if _, t, ok := strings.Cut(line, ","); ok {
+				title = t
+			}
+		}
+	}
+	return strings.TrimSpace(title), interval
+}
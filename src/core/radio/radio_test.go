@@ -0,0 +1,135 @@
+package radio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePlaylist(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Station
+	}{
+		{
+			name: "m3u",
+			data: "\ufeff#EXTM3U\n#EXTINF:-1 tvg-id=\"x\",Jazz FM\nhttp://jazz.example/stream\n\nhttp://untitled.example/live\n",
+			want: []Station{
+				{Name: "Jazz FM", URL: "http://jazz.example/stream"},
+				{Name: "http://untitled.example/live", URL: "http://untitled.example/live"},
+			},
+		},
+		{
+			name: "m3u title does not carry over",
+			data: "#EXTINF:-1,First\nhttp://a.example\n#EXTVLCOPT:network-caching=1000\nhttp://b.example\n",
+			want: []Station{
+				{Name: "First", URL: "http://a.example"},
+				{Name: "http://b.example", URL: "http://b.example"},
+			},
+		},
+		{
+			name: "pls",
+			data: "[playlist]\nFile1=http://one.example/stream\nTitle1=One\nFile2=http://two.example/stream\nNumberOfEntries=2\nVersion=2\n",
+			want: []Station{
+				{Name: "One", URL: "http://one.example/stream"},
+				{Name: "http://two.example/stream", URL: "http://two.example/stream"},
+			},
+		},
+		{
+			name: "empty",
+			data: "#EXTM3U\n",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParsePlaylist(tt.data)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d stations, got %v", len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("station %d: expected %+v, got %+v", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseStreamTitle(t *testing.T) {
+	tests := []struct {
+		block string
+		want  string
+		ok    bool
+	}{
+		{"StreamTitle='Artist - Title';StreamUrl='https://example.com';", "Artist - Title", true},
+		{"StreamTitle='Guns N' Roses - Don't Cry';StreamUrl='';", "Guns N' Roses - Don't Cry", true},
+		{"StreamTitle='It's a 'quoted' title'\x00\x00\x00", "It's a 'quoted' title", true},
+		{"StreamTitle='';", "", true},
+		{"StreamUrl='https://example.com';", "", false},
+		{"StreamTitle='unterminated", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseStreamTitle(tt.block)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseStreamTitle(%q) = %q, %v; expected %q, %v", tt.block, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFirstVariant(t *testing.T) {
+	master := "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=128000\n\nlow/index.m3u8\n#EXT-X-STREAM-INF:BANDWIDTH=256000\nhigh/index.m3u8\n"
+	if got := firstVariant(master); got != "low/index.m3u8" {
+		t.Fatalf("expected the first variant of a master playlist, got %q", got)
+	}
+
+	media := "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.0,Song\nsegment1.aac\n"
+	if got := firstVariant(media); got != "" {
+		t.Fatalf("expected no variant in a media playlist, got %q", got)
+	}
+}
+
+func TestHLSNowPlaying(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist string
+		title    string
+		interval time.Duration
+	}{
+		{
+			name:     "newest segment title",
+			playlist: "#EXTM3U\n#EXT-X-TARGETDURATION:10\n#EXTINF:10.0,Old Song\nseg1.aac\n#EXTINF:10.0, New Song \nseg2.aac\n",
+			title:    "New Song",
+			interval: 10 * time.Second,
+		},
+		{
+			name:     "untitled segments",
+			playlist: "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXTINF:4.0,\nseg1.ts\n",
+			title:    "",
+			interval: 4 * time.Second,
+		},
+		{
+			name:     "missing target duration",
+			playlist: "#EXTM3U\n#EXTINF:10.0,Song\nseg1.aac\n",
+			title:    "Song",
+			interval: 0,
+		},
+		{
+			name:     "invalid target duration",
+			playlist: "#EXTM3U\n#EXT-X-TARGETDURATION:abc\n#EXTINF:10.0,Song\nseg1.aac\n",
+			title:    "Song",
+			interval: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, interval := hlsNowPlaying(tt.playlist)
+			if title != tt.title || interval != tt.interval {
+				t.Fatalf("expected %q every %s, got %q every %s", tt.title, tt.interval, title, interval)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/vc"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// alwaysOnHandler handles the /247 command, which keeps the voice chat playing around the
+// clock from a saved playlist or from autoplay, like a radio station.
+func alwaysOnHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+	args := strings.Fields(Args(m))
+	if len(args) == 0 {
+		status := "Off"
+		if on, seed := db.Instance.GetAlwaysOn(chatID); on {
+			status = "📻 On, autoplaying from YouTube"
+			if strings.HasPrefix(seed, "tgpl_") {
+				status = fmt.Sprintf("📻 On, playing the playlist <code>%s</code>", html.EscapeString(seed))
+			}
+		}
+		_, err := m.ReplyText(c, fmt.Sprintf("<b>24/7 mode:</b> %s\n\n<b>Usage:</b> <code>/247 on [playlist_id]</code> or <code>/247 off</code>\n"+
+			"When the queue runs out, the bot refills it from the playlist, or autoplays from the last YouTube track, "+
+			"and it rejoins by itself when a new video chat starts.", status), replyOpts)
+		return err
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "on":
+		seed := ""
+		if len(args) > 1 {
+			seed = args[1]
+			playlist, err := db.Instance.GetPlaylist(seed)
+			if err != nil || len(playlist.Songs) == 0 {
+				_, err = m.ReplyText(c, "That playlist does not exist or is empty.", nil)
+				return err
+			}
+		} else if last := cache.ChatCache.GetLastYouTubeTrack(chatID); last != nil {
+			seed = last.TrackID
+		} else {
+			_, err := m.ReplyText(c, "Give a playlist ID, or play a YouTube track first so autoplay has something to start from.", nil)
+			return err
+		}
+
+		if err := db.Instance.SetAlwaysOn(chatID, true, seed); err != nil {
+			_, _ = m.ReplyText(c, "Failed to enable 24/7 mode.", nil)
+			return err
+		}
+		_, err := m.ReplyText(c, "📻 24/7 mode enabled. The voice chat will keep playing until you turn it off with <code>/247 off</code>.", replyOpts)
+		if err == nil && !cache.ChatCache.IsActive(chatID) {
+			if err := vc.Calls.StartAlwaysOn(c, chatID); err != nil {
+				_, _ = m.ReplyText(c, fmt.Sprintf("❌ Failed to start playback: %s", err.Error()), nil)
+			}
+		}
+		return err
+
+	case "off":
+		if err := db.Instance.SetAlwaysOn(chatID, false, ""); err != nil {
+			_, _ = m.ReplyText(c, "Failed to disable 24/7 mode.", nil)
+			return err
+		}
+		_, err := m.ReplyText(c, "24/7 mode disabled. Playback stops when the queue runs out.", nil)
+		return err
+	}
+
+	_, err := m.ReplyText(c, "<b>Usage:</b> <code>/247 on [playlist_id]</code> or <code>/247 off</code>", replyOpts)
+	return err
+}
@@ -0,0 +1,106 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"ashokshau/tgmusic/src/vc"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// bridgeHandler handles the /bridge command, which relays this chat's voice chat into
+// another chat's, optionally both ways. The sender must be an admin of both chats.
+func bridgeHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+	args := strings.Fields(strings.ToLower(Args(m)))
+	if len(args) == 0 {
+		status := "Off"
+		if other := vc.Calls.BridgedWith(chatID); other != 0 {
+			direction := "one way"
+			if vc.Calls.BridgeBothWays(chatID) {
+				direction = "both ways"
+			}
+			status = fmt.Sprintf("🔗 Connected with <code>%d</code> (%s)", other, direction)
+		}
+		_, err := m.ReplyText(c, fmt.Sprintf("<b>Voice chat bridge:</b> %s\n\n<b>Usage:</b> <code>/bridge &lt;chat_id&gt; [both]</code> or <code>/bridge stop</code>\n"+
+			"Relays this voice chat into the other chat's. Add <code>both</code> to relay the other chat back here as well.", status), replyOpts)
+		return err
+	}
+
+	if !isChatAdmin(c, chatID, m.SenderID()) {
+		_, err := m.ReplyText(c, "You must be an administrator of this chat to bridge it.", nil)
+		return err
+	}
+
+	if args[0] == "stop" {
+		if err := vc.Calls.StopBridge(chatID, "stopped by "+firstName(c, m)); err != nil {
+			_, err = m.ReplyText(c, "This voice chat is not bridged.", nil)
+			return err
+		}
+		return nil
+	}
+
+	other, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || other >= 0 {
+		_, err = m.ReplyText(c, "Please give the numeric ID of the other group, such as <code>-1001234567890</code>.", replyOpts)
+		return err
+	}
+	if other == chatID {
+		_, err = m.ReplyText(c, "A voice chat cannot be bridged to itself.", nil)
+		return err
+	}
+	both := len(args) > 1 && args[1] == "both"
+
+	if !checkBotAdmin(c, other, func(string) {
+		_, _ = m.ReplyText(c, "I must be an administrator with the invite users permission in the other chat.", nil)
+	}) {
+		return td.EndGroups
+	}
+	if !isChatAdmin(c, other, m.SenderID()) {
+		_, err = m.ReplyText(c, "You must be an administrator of the other chat to bridge it.", nil)
+		return err
+	}
+
+	updater, err := m.ReplyText(c, "🔗 Connecting the voice chats...", nil)
+	if err != nil {
+		c.Logger.Warn("failed to send message", "error", err)
+		return td.EndGroups
+	}
+
+	if err = vc.Calls.StartBridge(c, chatID, other, both); err != nil {
+		_, _ = updater.EditText(c, fmt.Sprintf("❌ Failed to bridge the voice chats: %s", err.Error()), &td.EditTextMessageOpts{ParseMode: "HTML"})
+		return nil
+	}
+
+	direction := "This voice chat is now relayed into"
+	if both {
+		direction = "This voice chat is now connected both ways with"
+	}
+	_, _ = updater.EditText(c, fmt.Sprintf("🔗 %s <code>%d</code>. Use <code>/bridge stop</code> to end it.", direction, other), &td.EditTextMessageOpts{ParseMode: "HTML"})
+
+	chatTitle := fmt.Sprintf("%d", chatID)
+	if chat, err := m.GetChat(c); err == nil {
+		chatTitle = chat.Title
+	}
+	text := fmt.Sprintf("🔗 The voice chat of <b>%s</b> is now relayed into this one, started by %s.", html.EscapeString(chatTitle), html.EscapeString(firstName(c, m)))
+	if both {
+		text = fmt.Sprintf("🔗 This voice chat is now connected both ways with <b>%s</b>, started by %s.", html.EscapeString(chatTitle), html.EscapeString(firstName(c, m)))
+	}
+	_, _ = c.SendTextMessage(other, text, &td.SendTextMessageOpts{ParseMode: "HTML"})
+	return nil
+}
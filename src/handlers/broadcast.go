@@ -10,6 +10,7 @@ package handlers
 
 import (
 	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/utils"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -25,30 +26,6 @@ var (
 	broadcastInProgress atomic.Bool
 )
 
-func getFloodWait(err error) int {
-	if err == nil {
-		return 0
-	}
-
-	type retryError interface {
-		GetRetryAfter() int
-	}
-
-	if re, ok := err.(retryError); ok {
-		return re.GetRetryAfter()
-	}
-
-	if tdErr, ok := err.(*td.Error); ok {
-		return tdErr.GetRetryAfter()
-	}
-
-	if tdErr, ok := err.(td.Error); ok {
-		return tdErr.GetRetryAfter()
-	}
-
-	return 0
-}
-
 func cancelBroadcastHandler(c *td.Client, m *td.Message) error {
 	if !isDev(c, m) {
 		return td.EndGroups
@@ -175,7 +152,7 @@ Examples:
 				}
 				time.Sleep(200 * time.Millisecond)
 			} else {
-				wait := getFloodWait(errSend)
+				wait := utils.GetFloodWait(errSend)
 				if wait > 0 {
 					time.Sleep(time.Duration(wait+30) * time.Second)
 					continue
@@ -30,6 +30,7 @@ func playCallbackHandler(c *td.Client, cb *td.UpdateNewCallbackQuery) error {
 	}
 
 	chatID := cb.ChatId
+	volume := db.Instance.GetVolume(chatID)
 	user, err := c.GetUser(cb.SenderUserId)
 	if err != nil {
 		user = &td.User{FirstName: "Unknown", Id: cb.SenderUserId}
@@ -38,14 +39,14 @@ func playCallbackHandler(c *td.Client, cb *td.UpdateNewCallbackQuery) error {
 	if !cache.ChatCache.IsActive(chatID) {
 		text := "There is no active playback."
 		_ = cb.Answer(c, 0, false, text, "")
-		_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons(""), ParseMode: "HTML", DisableWebPagePreview: true})
+		_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("", 0), ParseMode: "HTML", DisableWebPagePreview: true})
 		return nil
 	}
 
 	currentTrack := cache.ChatCache.GetPlayingTrack(chatID)
 	if currentTrack == nil {
 		_ = cb.Answer(c, 0, false, "There is no active playback.", "")
-		_, _ = cb.EditMessageText(c, "There is no active playback.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons(""), ParseMode: "HTML", DisableWebPagePreview: true})
+		_, _ = cb.EditMessageText(c, "There is no active playback.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("", 0), ParseMode: "HTML", DisableWebPagePreview: true})
 		return nil
 	}
 
@@ -56,16 +57,16 @@ func playCallbackHandler(c *td.Client, cb *td.UpdateNewCallbackQuery) error {
 		return fmt.Sprintf("%s <b>%s</b>\n\n<b>Track:</b> <a href='%s'>%s</a>\n<b>Duration:</b> %s\n<b>Requested by:</b> %s",
 			emoji, status,
 			escURL, escName,
-			utils.SecToMin(currentTrack.Duration),
+			utils.TrackDuration(currentTrack),
 			escUser,
 		)
 	}
 
 	switch {
 	case strings.Contains(data, "play_skip"):
-		if err := vc.Calls.PlayNext(c, chatID); err != nil {
+		if err := vc.Calls.Skip(c, chatID); err != nil {
 			_ = cb.Answer(c, 0, false, "Unable to skip the current track.", "")
-			_, _ = cb.EditMessageText(c, "Unable to skip the current track.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons(""), ParseMode: "HTML", DisableWebPagePreview: true})
+			_, _ = cb.EditMessageText(c, "Unable to skip the current track.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("", 0), ParseMode: "HTML", DisableWebPagePreview: true})
 			return nil
 		}
 		_ = cb.Answer(c, 0, false, "Track skipped.", "")
@@ -75,57 +76,103 @@ func playCallbackHandler(c *td.Client, cb *td.UpdateNewCallbackQuery) error {
 	case strings.Contains(data, "play_stop"):
 		if err := vc.Calls.Stop(chatID, false); err != nil {
 			_ = cb.Answer(c, 0, false, "Unable to stop playback.", "")
-			_, _ = cb.EditMessageText(c, "Unable to stop playback.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons(""), ParseMode: "HTML", DisableWebPagePreview: true})
+			_, _ = cb.EditMessageText(c, "Unable to stop playback.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("", 0), ParseMode: "HTML", DisableWebPagePreview: true})
 			return nil
 		}
 
 		msg := fmt.Sprintf("<b>Playback stopped.</b>\nRequested by: %s", html.EscapeString(user.FirstName))
 		_ = cb.Answer(c, 0, false, "Playback stopped.", "")
-		_, err := cb.EditMessageText(c, msg, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons(""), ParseMode: "HTML", DisableWebPagePreview: true})
+		_, err := cb.EditMessageText(c, msg, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("", 0), ParseMode: "HTML", DisableWebPagePreview: true})
 		return err
 
 	case strings.Contains(data, "play_pause"):
 		if _, err = vc.Calls.Pause(chatID); err != nil {
 			_ = cb.Answer(c, 0, false, "Unable to pause playback.", "")
-			_, _ = cb.EditMessageText(c, "Unable to pause playback.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons(""), ParseMode: "HTML", DisableWebPagePreview: true})
+			_, _ = cb.EditMessageText(c, "Unable to pause playback.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("", 0), ParseMode: "HTML", DisableWebPagePreview: true})
 			return nil
 		}
 		_ = cb.Answer(c, 0, false, "Playback paused.", "")
 		text := buildTrackMessage("Paused", "⏸") + fmt.Sprintf("\n\nPaused by %s", html.EscapeString(user.FirstName))
-		_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("pause"), ParseMode: "HTML", DisableWebPagePreview: true})
+		_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("pause", volume), ParseMode: "HTML", DisableWebPagePreview: true})
 		return nil
 
 	case strings.Contains(data, "play_resume"):
 		if _, err := vc.Calls.Resume(chatID); err != nil {
 			_ = cb.Answer(c, 0, false, "Unable to resume playback.", "")
-			_, _ = cb.EditMessageText(c, "Unable to resume playback.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("pause"), ParseMode: "HTML", DisableWebPagePreview: true})
+			_, _ = cb.EditMessageText(c, "Unable to resume playback.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("pause", volume), ParseMode: "HTML", DisableWebPagePreview: true})
 			return nil
 		}
+		if err := vc.Calls.ApplyPendingRestart(c, chatID); err != nil {
+			c.Logger.Warn("Failed to restart stream", "chatID", chatID, "error", err)
+		}
 		_ = cb.Answer(c, 0, false, "Playback resumed.", "")
 		text := buildTrackMessage("Now Playing", "▶") + fmt.Sprintf("\n\nResumed by %s", html.EscapeString(user.FirstName))
-		_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("resume"), ParseMode: "HTML", DisableWebPagePreview: true})
+		_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("resume", volume), ParseMode: "HTML", DisableWebPagePreview: true})
 		return nil
 
 	case strings.Contains(data, "play_mute"):
 		if _, err := vc.Calls.Mute(chatID); err != nil {
 			_ = cb.Answer(c, 0, false, "Unable to mute playback.", "")
-			_, _ = cb.EditMessageText(c, "Unable to mute playback.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("mute"), ParseMode: "HTML", DisableWebPagePreview: true})
+			_, _ = cb.EditMessageText(c, "Unable to mute playback.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("mute", volume), ParseMode: "HTML", DisableWebPagePreview: true})
 			return nil
 		}
 		_ = cb.Answer(c, 0, false, "Playback muted.", "")
 		text := buildTrackMessage("Muted", "🔇") + fmt.Sprintf("\n\nMuted by %s", html.EscapeString(user.FirstName))
-		_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("mute"), ParseMode: "HTML", DisableWebPagePreview: true})
+		_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("mute", volume), ParseMode: "HTML", DisableWebPagePreview: true})
 		return nil
 
 	case strings.Contains(data, "play_unmute"):
 		if _, err := vc.Calls.Unmute(chatID); err != nil {
 			_ = cb.Answer(c, 0, false, "Unable to unmute playback.", "")
-			_, _ = cb.EditMessageText(c, "Unable to unmute playback.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("unmute"), ParseMode: "HTML"})
+			_, _ = cb.EditMessageText(c, "Unable to unmute playback.", &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("unmute", volume), ParseMode: "HTML"})
 			return nil
 		}
+		if err := vc.Calls.ApplyPendingRestart(c, chatID); err != nil {
+			c.Logger.Warn("Failed to restart stream", "chatID", chatID, "error", err)
+		}
 		_ = cb.Answer(c, 0, false, "Playback unmuted.", "")
 		text := buildTrackMessage("Now Playing", "▶") + fmt.Sprintf("\n\nUnmuted by %s", html.EscapeString(user.FirstName))
-		_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("unmute"), DisableWebPagePreview: true})
+		_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("unmute", volume), DisableWebPagePreview: true})
+		return nil
+
+	case strings.Contains(data, "play_volume"):
+		_ = cb.Answer(c, 0, false, fmt.Sprintf("Volume: %d%%", volume), "")
+		return nil
+
+	case strings.Contains(data, "play_voldown"), strings.Contains(data, "play_volup"):
+		newVolume := volume + volumeStep
+		if strings.Contains(data, "play_voldown") {
+			newVolume = volume - volumeStep
+		}
+		newVolume = max(vc.MinVolume, min(vc.MaxVolume, newVolume))
+		if newVolume == volume {
+			_ = cb.Answer(c, 0, false, fmt.Sprintf("Volume is already at %d%%.", volume), "")
+			return nil
+		}
+
+		if err = db.Instance.SetVolume(chatID, newVolume); err != nil {
+			_ = cb.Answer(c, 0, false, "Failed to save the volume.", "")
+			return nil
+		}
+		if deferred, err := vc.Calls.RestartIfAudible(c, chatID); err != nil {
+			c.Logger.Warn("Failed to restart stream", "chatID", chatID, "error", err)
+			_ = cb.Answer(c, 0, false, fmt.Sprintf("Volume: %d%%. It will apply from the next track.", newVolume), "")
+		} else if deferred {
+			_ = cb.Answer(c, 0, false, fmt.Sprintf("Volume: %d%%. It will apply when playback continues.", newVolume), "")
+		} else {
+			_ = cb.Answer(c, 0, false, fmt.Sprintf("Volume: %d%%", newVolume), "")
+		}
+
+		mode := vc.Calls.PlaybackMode(chatID)
+		status, emoji := "Now Playing", "▶"
+		switch mode {
+		case "pause":
+			status, emoji = "Paused", "⏸"
+		case "mute":
+			status, emoji = "Muted", "🔇"
+		}
+		text := buildTrackMessage(status, emoji) + fmt.Sprintf("\n\nVolume set to %d%% by %s", newVolume, html.EscapeString(user.FirstName))
+		_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons(mode, newVolume), ParseMode: "HTML", DisableWebPagePreview: true})
 		return nil
 
 	case strings.Contains(data, "play_add_to_list"):
@@ -175,7 +222,7 @@ func playCallbackHandler(c *td.Client, cb *td.UpdateNewCallbackQuery) error {
 			_ = cb.Answer(c, 0, false, "Track not found in queue.", "")
 			return nil
 		}
-		if err := vc.Calls.PlayNext(c, chatID); err != nil {
+		if err := vc.Calls.Skip(c, chatID); err != nil {
 			_ = cb.Answer(c, 0, false, "Unable to play the track.", "")
 			return nil
 		}
@@ -185,7 +232,7 @@ func playCallbackHandler(c *td.Client, cb *td.UpdateNewCallbackQuery) error {
 	}
 
 	text := buildTrackMessage("Now Playing", "▶")
-	_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("resume"), ParseMode: "HTML", DisableWebPagePreview: true})
+	_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.ControlButtons("resume", volume), ParseMode: "HTML", DisableWebPagePreview: true})
 	return nil
 }
 
@@ -0,0 +1,145 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/vc"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// restartForSettings restarts the current stream, if any, so that a changed audio setting
+// is heard right away. A paused or muted stream restarts once it is audible again. It returns
+// a note to append to the reply when the change does not apply right away.
+func restartForSettings(c *td.Client, chatID int64) string {
+	if !cache.ChatCache.IsActive(chatID) {
+		return ""
+	}
+	deferred, err := vc.Calls.RestartIfAudible(c, chatID)
+	if err != nil {
+		c.Logger.Warn("Failed to restart stream", "chatID", chatID, "error", err)
+		return "\nIt will apply from the next track."
+	}
+	if deferred {
+		return "\nIt will apply when playback continues."
+	}
+	return ""
+}
+
+// effectHandler handles the /effect command.
+func effectHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+	names := strings.Join(vc.EffectNames(), "|")
+
+	effect := strings.ToLower(Args(m))
+	if effect == "" {
+		current := db.Instance.GetEffect(chatID)
+		if current == "" {
+			current = "off"
+		}
+		text := fmt.Sprintf("<b>Effect:</b> %s\n\n<b>Usage:</b> <code>/effect [%s|off]</code>", current, names)
+		_, err := m.ReplyText(c, text, replyOpts)
+		return err
+	}
+
+	if effect == "off" {
+		effect = ""
+	} else if !vc.HasEffect(effect) {
+		_, err := m.ReplyText(c, fmt.Sprintf("Unknown effect. Use one of: %s, off.", strings.ReplaceAll(names, "|", ", ")), nil)
+		return err
+	}
+
+	if err := db.Instance.SetEffect(chatID, effect); err != nil {
+		_, _ = m.ReplyText(c, "Failed to save the effect.", nil)
+		return err
+	}
+
+	text := "Audio effect has been turned off."
+	if effect != "" {
+		text = fmt.Sprintf("Audio effect has been set to %s.", effect)
+	}
+	text += fmt.Sprintf("\nChanged by: %s", firstName(c, m)) + restartForSettings(c, chatID)
+	_, err := m.ReplyText(c, text, nil)
+	return err
+}
+
+// speedHandler handles the /speed command.
+func speedHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+
+	args := strings.TrimSuffix(strings.ToLower(Args(m)), "x")
+	if args == "" {
+		text := fmt.Sprintf("<b>Speed:</b> %gx\n\n<b>Usage:</b> <code>/speed [%g-%g]</code>", db.Instance.GetSpeed(chatID), vc.MinSpeed, vc.MaxSpeed)
+		_, err := m.ReplyText(c, text, replyOpts)
+		return err
+	}
+
+	speed, err := strconv.ParseFloat(args, 64)
+	if err != nil || speed < vc.MinSpeed || speed > vc.MaxSpeed {
+		_, err = m.ReplyText(c, fmt.Sprintf("Speed must be a number between %g and %g.", vc.MinSpeed, vc.MaxSpeed), nil)
+		return err
+	}
+
+	if err = db.Instance.SetSpeed(chatID, speed); err != nil {
+		_, _ = m.ReplyText(c, "Failed to save the speed.", nil)
+		return err
+	}
+
+	text := fmt.Sprintf("Playback speed has been set to %gx.\nChanged by: %s", speed, firstName(c, m)) + restartForSettings(c, chatID)
+	_, err = m.ReplyText(c, text, nil)
+	return err
+}
+
+// volumeStep is how much the volume buttons change the level by, in percent.
+const volumeStep = 20
+
+// volumeHandler handles the /volume command.
+func volumeHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+
+	args := strings.TrimSuffix(Args(m), "%")
+	if args == "" {
+		text := fmt.Sprintf("<b>Volume:</b> %d%%\n\n<b>Usage:</b> <code>/volume [%d-%d]</code>", db.Instance.GetVolume(chatID), vc.MinVolume, vc.MaxVolume)
+		_, err := m.ReplyText(c, text, replyOpts)
+		return err
+	}
+
+	volume, err := strconv.Atoi(args)
+	if err != nil || volume < vc.MinVolume || volume > vc.MaxVolume {
+		_, err = m.ReplyText(c, fmt.Sprintf("Volume must be a number between %d and %d.", vc.MinVolume, vc.MaxVolume), nil)
+		return err
+	}
+
+	if err = db.Instance.SetVolume(chatID, volume); err != nil {
+		_, _ = m.ReplyText(c, "Failed to save the volume.", nil)
+		return err
+	}
+
+	text := fmt.Sprintf("Volume has been set to %d%%.\nChanged by: %s", volume, firstName(c, m)) + restartForSettings(c, chatID)
+	_, err = m.ReplyText(c, text, nil)
+	return err
+}
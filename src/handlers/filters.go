@@ -46,6 +46,21 @@ func checkBotAdmin(c *td.Client, chatID int64, replyErr func(msg string)) bool {
 	}
 }
 
+// isChatAdmin reports whether a user is the owner or an administrator of a chat. Unlike
+// adminMode it does not depend on the chat's admin mode or authorized users.
+func isChatAdmin(c *td.Client, chatID, userID int64) bool {
+	status, err := cache.GetUserAdmin(c, chatID, userID, false)
+	if err != nil {
+		return false
+	}
+	switch status.Status.(type) {
+	case *td.ChatMemberStatusCreator, *td.ChatMemberStatusAdministrator:
+		return true
+	default:
+		return false
+	}
+}
+
 func adminMode(c *td.Client, m *td.Message) bool {
 
 	if m.IsPrivate() {
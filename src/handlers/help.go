@@ -40,6 +40,7 @@ func getHelpCategories() map[string]struct {
     <tr><td><code>/vplay [song]</code></td><td>Play a video in the group video chat.</td></tr>
     <tr><td><code>/fplay [song]</code></td><td>Play a track immediately, skipping the current queue.</td></tr>
     <tr><td><code>/fvplay [song]</code></td><td>Play a video immediately, skipping the current queue.</td></tr>
+    <tr><td><code>/radio [station]</code></td><td>List the radio stations, or play one by number, name or stream URL. The station's now-playing title is read over a second connection, which doubles the bandwidth used per station.</td></tr>
   </table>
 </details>
 
@@ -51,7 +52,9 @@ func getHelpCategories() map[string]struct {
     <tr><td><code>/help</code></td><td>Open the interactive help menu.</td></tr>
     <tr><td><code>/ping</code></td><td>Display the bot's response time and system information.</td></tr>
     <tr><td><code>/privacy</code></td><td>View the bot's privacy policy.</td></tr>
-    <tr><td><code>/queue</code></td><td>Display the current playback queue.</td></tr>
+    <tr><td><code>/queue</code></td><td>Browse the playback queue with buttons to play, reorder or remove tracks.</td></tr>
+    <tr><td><code>/history</code></td><td>List recently played tracks and who requested them.</td></tr>
+    <tr><td><code>/voteskip</code></td><td>Vote to skip the current track. It is skipped once enough voice chat listeners agree.</td></tr>
   </table>
 </details>`,
 			Markup: core.BackHelpMenuKeyboard(),
@@ -65,9 +68,22 @@ func getHelpCategories() map[string]struct {
   <table bordered striped>
     <tr><th>Command</th><th>Description</th></tr>
     <tr><td><code>/skip</code></td><td>Skip the currently playing track.</td></tr>
+    <tr><td><code>/skipto [index]</code></td><td>Skip ahead to a track in the queue.</td></tr>
+    <tr><td><code>/previous</code></td><td>Replay the previously played track.</td></tr>
     <tr><td><code>/pause</code></td><td>Pause playback.</td></tr>
     <tr><td><code>/resume</code></td><td>Resume playback.</td></tr>
-    <tr><td><code>/seek [seconds]</code></td><td>Jump to a specific position in the current track.</td></tr>
+    <tr><td><code>/seek [+|-]time</code></td><td>Jump to a position like 1:23, or move by +30 / -15 seconds.</td></tr>
+    <tr><td><code>/rewind [seconds]</code></td><td>Go back in the current track (10 seconds by default).</td></tr>
+    <tr><td><code>/replay</code></td><td>Restart the current track from the beginning.</td></tr>
+    <tr><td><code>/effect [name]</code></td><td>Apply an audio effect: bass, nightcore, slowed, 8d or off.</td></tr>
+    <tr><td><code>/speed [0.5-2.0]</code></td><td>Change the playback speed.</td></tr>
+    <tr><td><code>/volume [1-200]</code></td><td>Change the playback volume.</td></tr>
+    <tr><td><code>/vmode [camera|screen]</code></td><td>Show video as the camera or as a screen share.</td></tr>
+    <tr><td><code>/ingest [start|stop|key]</code></td><td>Relay a live stream pushed from OBS or other streaming software into the voice chat. The address is sent privately.</td></tr>
+    <tr><td><code>/record start [bot]|stop</code></td><td>Record the voice chat, or only the bot's playback, and upload the audio when stopped.</td></tr>
+    <tr><td><code>/bridge &lt;chat_id&gt; [both]|stop</code></td><td>Relay this voice chat into another group's, optionally both ways. You must be an admin of both groups.</td></tr>
+    <tr><td><code>/247 on [playlist_id]|off</code></td><td>Keep the voice chat playing around the clock from a playlist or autoplay, rejoining when a new video chat starts.</td></tr>
+    <tr><td><code>/soundboard [name|add|rm]</code></td><td>Play a saved clip over the current track, or save (in reply to audio) and remove clips.</td></tr>
     <tr><td><code>/mute</code></td><td>Mute the voice chat audio.</td></tr>
     <tr><td><code>/unmute</code></td><td>Unmute the voice chat audio.</td></tr>
   </table>
@@ -78,7 +94,11 @@ func getHelpCategories() map[string]struct {
   <table bordered striped>
     <tr><th>Command</th><th>Description</th></tr>
     <tr><td><code>/remove [index]</code></td><td>Remove a track from the queue by its position.</td></tr>
+    <tr><td><code>/move [from] [to]</code></td><td>Move a track to another position in the queue.</td></tr>
+    <tr><td><code>/shuffle</code></td><td>Shuffle the upcoming tracks.</td></tr>
+    <tr><td><code>/clearqueue</code></td><td>Remove all upcoming tracks, keeping the current one.</td></tr>
     <tr><td><code>/loop [0-10]</code></td><td>Repeat the current track the specified number of times.</td></tr>
+    <tr><td><code>/repeat [off|track|queue]</code></td><td>Repeat the current track or keep the whole queue in rotation.</td></tr>
     <tr><td><code>/auth</code></td><td>Authorize a user to use administrator commands.</td></tr>
     <tr><td><code>/unauth</code></td><td>Remove a user's authorization.</td></tr>
     <tr><td><code>/authlist</code></td><td>Show all authorized users in the current chat.</td></tr>
@@ -111,7 +131,7 @@ func getHelpCategories() map[string]struct {
   <summary>Chat Settings</summary>
   <table bordered striped>
     <tr><th>Command</th><th>Description</th></tr>
-    <tr><td><code>/settings</code></td><td>Manage chat settings, including play mode, administrator mode, command auto-delete, and language preferences.</td></tr>
+    <tr><td><code>/settings</code></td><td>Manage chat settings, including play mode, administrator mode, command auto-delete, language preferences, queue limits, and fair queueing by requester.</td></tr>
   </table>
 </details>`,
 			Markup: core.BackHelpMenuKeyboard(),
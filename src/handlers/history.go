@@ -0,0 +1,64 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"ashokshau/tgmusic/src/utils"
+	"fmt"
+	"html"
+	"strings"
+
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/vc"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// previousHandler handles the /previous command.
+func previousHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+
+	if len(cache.ChatCache.GetHistory(chatID)) == 0 {
+		_, err := m.ReplyText(c, "There is no previously played track.", nil)
+		return err
+	}
+
+	if err := vc.Calls.PlayPrevious(c, chatID); err != nil {
+		_, err = m.ReplyText(c, fmt.Sprintf("Unable to play the previous track: %s", err.Error()), nil)
+		return err
+	}
+	return nil
+}
+
+// historyHandler handles the /history command.
+func historyHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	history := cache.ChatCache.GetHistory(m.ChatId)
+	if len(history) == 0 {
+		_, err := m.ReplyText(c, "Nothing has been played in this chat yet.", nil)
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("<b>Recently Played:</b>\n\n")
+	for i, song := range history {
+		fmt.Fprintf(&b, "%d. <code>%s</code> | %s min\n└ Requested by: %s\n",
+			i+1, html.EscapeString(truncate(song.Name, 45)), utils.SecToMin(song.Duration), html.EscapeString(song.User))
+	}
+
+	_, err := m.ReplyText(c, b.String(), replyOpts)
+	return err
+}
@@ -0,0 +1,155 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"ashokshau/tgmusic/config"
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/utils"
+	"ashokshau/tgmusic/src/vc"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// ingestActive reports whether a chat is relaying a live ingest.
+func ingestActive(chatID int64) bool {
+	track := cache.ChatCache.GetPlayingTrack(chatID)
+	return track != nil && track.Platform == utils.Ingest
+}
+
+// stopIngest ends a chat's live ingest, moving on to the next track if it is playing.
+func stopIngest(c *td.Client, chatID int64) error {
+	defer vc.Calls.ReleaseIngest(chatID)
+	if ingestActive(chatID) {
+		return vc.Calls.Skip(c, chatID)
+	}
+	return nil
+}
+
+// ingestHandler handles the /ingest command, which relays a stream pushed from streaming
+// software such as OBS into the voice chat. The stream key is only sent privately, and only
+// chat administrators may use it whatever the chat's admin mode.
+func ingestHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+	if !isChatAdmin(c, chatID, m.SenderID()) {
+		_, _ = m.ReplyText(c, "You must be an administrator to use this command.", nil)
+		return td.EndGroups
+	}
+	if !vc.IngestEnabled() {
+		_, err := m.ReplyText(c, "Live ingest is not enabled on this bot.", nil)
+		return err
+	}
+
+	switch strings.ToLower(Args(m)) {
+	case "start":
+		return startIngest(c, m)
+
+	case "stop":
+		if !ingestActive(chatID) {
+			vc.Calls.ReleaseIngest(chatID)
+			_, err := m.ReplyText(c, "No live ingest is running.", nil)
+			return err
+		}
+		if err := stopIngest(c, chatID); err != nil {
+			c.Logger.Warn("failed to stop the live ingest", "error", err)
+		}
+		_, err := m.ReplyText(c, fmt.Sprintf("Live ingest stopped by %s.", firstName(c, m)), nil)
+		return err
+
+	case "key":
+		if _, err := db.Instance.NewIngestKey(chatID); err != nil {
+			_, _ = m.ReplyText(c, "Failed to generate a new stream key.", nil)
+			return err
+		}
+		text := "A new stream key was generated. Use <code>/ingest start</code> to receive it."
+		if ingestActive(chatID) {
+			_ = stopIngest(c, chatID)
+			text = "A new stream key was generated and the running ingest was stopped. Use <code>/ingest start</code> to receive the new key."
+		}
+		_, err := m.ReplyText(c, text, replyOpts)
+		return err
+	}
+
+	status := "Off"
+	if ingestActive(chatID) {
+		status = "🔴 Live"
+	}
+	text := fmt.Sprintf("<b>Live ingest:</b> %s\n\n<b>Usage:</b> <code>/ingest [start|stop|key]</code>\n"+
+		"<code>start</code> sends you the address to stream to and relays it into the voice chat, "+
+		"<code>stop</code> ends it and <code>key</code> replaces the stream key.", status)
+	_, err := m.ReplyText(c, text, replyOpts)
+	return err
+}
+
+// startIngest sends the stream endpoint to the admin privately and queues the live ingest
+// ahead of the queue.
+func startIngest(c *td.Client, m *td.Message) error {
+	chatID := m.ChatId
+	if ingestActive(chatID) {
+		_, err := m.ReplyText(c, "Live ingest is already running. Use <code>/ingest stop</code> to end it.", replyOpts)
+		return err
+	}
+
+	if limit := db.Instance.GetQueueLimit(chatID); cache.ChatCache.GetQueueLength(chatID) >= limit {
+		_, _ = m.ReplyText(c, fmt.Sprintf("Queue is full (max %d tracks). Use /end to clear.", limit), nil)
+		return td.EndGroups
+	}
+
+	if db.Instance.GetIngestKey(chatID) == "" {
+		if _, err := db.Instance.NewIngestKey(chatID); err != nil {
+			_, _ = m.ReplyText(c, "Failed to generate a stream key.", nil)
+			return err
+		}
+	}
+
+	_, endpoint, err := vc.Calls.ReserveIngest(chatID)
+	if err != nil {
+		_, err = m.ReplyText(c, fmt.Sprintf("❌ %s", err.Error()), nil)
+		return err
+	}
+
+	chatTitle := "this chat"
+	if chat, err := m.GetChat(c); err == nil {
+		chatTitle = chat.Title
+	}
+	text := fmt.Sprintf("<b>🔴 Live ingest for %s</b>\n\n<b>Server:</b> <code>%s</code>\n<b>Stream key:</b> <code>%s</code>\n\n"+
+		"<b>Full address:</b> <code>%s</code>\n\nKeep the stream key private: anyone with it can stream into the voice chat.",
+		html.EscapeString(chatTitle), html.EscapeString(endpoint.Server), html.EscapeString(endpoint.Key), html.EscapeString(endpoint.URL))
+	if config.IngestProtocol == "rtmp" {
+		text += "\n\n<b>Note:</b> RTMP ingest is not authenticated, so anyone who can reach this address can stream into the voice chat while it is open."
+	}
+	if _, err = c.SendTextMessage(m.SenderID(), text, &td.SendTextMessageOpts{ParseMode: "HTML", DisableWebPagePreview: true}); err != nil {
+		vc.Calls.ReleaseIngest(chatID)
+		_, err = m.ReplyText(c, "I couldn't message you the stream key. Start me in private and try again.", nil)
+		return err
+	}
+
+	updater, err := m.ReplyText(c, "🔴 Waiting for the stream. The address was sent to you privately.", nil)
+	if err != nil {
+		c.Logger.Warn("failed to send message", "error", err)
+		return td.EndGroups
+	}
+
+	track := utils.MusicTrack{
+		Title:    "Live ingest",
+		Id:       fmt.Sprintf("ingest_%d", chatID),
+		Platform: utils.Ingest,
+		IsLive:   true,
+	}
+	return handleSingleTrack(c, m, updater, track, "", chatID, true, true)
+}
@@ -0,0 +1,92 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"ashokshau/tgmusic/src/utils"
+	"errors"
+	"fmt"
+	"slices"
+
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+)
+
+// queueTracks adds the leading tracks that fit within the chat's queue length, per-user and
+// total duration limits. The queue store checks and adds them in one step, so concurrent
+// requests cannot overfill the queue. Tracks of unknown length that can be probed are measured
+// first. It returns the tracks added, the new queue length and the reason the remaining tracks
+// were rejected, which is empty when every track fits.
+func queueTracks(chatID int64, tracks []*utils.CachedTrack, front bool) ([]*utils.CachedTrack, int, string) {
+	limits := cache.QueueLimits{
+		MaxTracks:   db.Instance.GetQueueLimit(chatID),
+		MaxPerUser:  db.Instance.GetUserQueueLimit(chatID),
+		MaxDuration: db.Instance.GetQueueDurationLimit(chatID),
+	}
+	if limits.MaxDuration > 0 {
+		for _, t := range tracks {
+			if t.Duration == 0 && !t.IsLive && t.FilePath != "" {
+				t.Duration = utils.GetMediaDuration(t.FilePath)
+			}
+		}
+	}
+
+	added, length, err := cache.ChatCache.AddSongsLimited(chatID, tracks, limits, front)
+	var reason string
+	switch {
+	case errors.Is(err, cache.ErrQueueFull):
+		reason = fmt.Sprintf("Queue is full (max %d tracks).", limits.MaxTracks)
+	case errors.Is(err, cache.ErrUserQueueFull):
+		reason = fmt.Sprintf("You can only have %d tracks in the queue at a time.", limits.MaxPerUser)
+	case errors.Is(err, cache.ErrQueueTooLong):
+		reason = fmt.Sprintf("The queue can hold at most %s min of music.", utils.SecToMin(limits.MaxDuration))
+	case added == 0:
+		reason = "Failed to add to the queue, please try again."
+	}
+	return tracks[:added], length, reason
+}
+
+// checkDuplicate applies the chat's duplicate mode to a track about to be queued.
+// It returns false with a reason when the track must be rejected, and true with a
+// notice when the track may be queued but is already in the queue.
+func checkDuplicate(chatID int64, track *utils.CachedTrack) (bool, string) {
+	mode := db.Instance.GetDuplicateMode(chatID)
+	if mode == utils.DuplicateAllow {
+		return true, ""
+	}
+
+	existing := cache.ChatCache.FindDuplicate(chatID, track)
+	if existing == nil {
+		return true, ""
+	}
+	if mode == utils.DuplicateWarn {
+		return true, fmt.Sprintf("Note: %s is already in the queue.", existing.Name)
+	}
+	return false, "Track already in queue or playing."
+}
+
+// dropDuplicates removes tracks that are already queued, or repeated within tracks,
+// when the chat rejects duplicates. It returns the remaining tracks and how many were dropped.
+func dropDuplicates(chatID int64, tracks []*utils.CachedTrack) ([]*utils.CachedTrack, int) {
+	if db.Instance.GetDuplicateMode(chatID) != utils.DuplicateReject {
+		return tracks, 0
+	}
+
+	kept := make([]*utils.CachedTrack, 0, len(tracks))
+	for _, t := range tracks {
+		if cache.ChatCache.FindDuplicate(chatID, t) != nil {
+			continue
+		}
+		if slices.ContainsFunc(kept, func(k *utils.CachedTrack) bool { return cache.SameTrack(k, t) }) {
+			continue
+		}
+		kept = append(kept, t)
+	}
+	return kept, len(tracks) - len(kept)
+}
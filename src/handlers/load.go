@@ -40,15 +40,37 @@ func LoadModules(c *gotdbot.Client) {
 	c.OnCommand("privacy", privacyHandler)
 	c.OnCommand("autoplay", autoplayHandler)
 	c.OnCommand("loop", loopHandler)
+	c.OnCommand("repeat", repeatHandler)
 	c.OnCommand("pause", pauseHandler)
 	c.OnCommand("resume", resumeHandler)
 	c.OnCommand("cplist", createPlaylistHandler)
 	c.OnCommand("createplaylist", createPlaylistHandler)
 	c.OnCommand("deleteplaylist", deletePlaylistHandler)
 	c.OnCommand("queue", queueHandler)
+	c.OnCommand("history", historyHandler)
+	c.OnCommand("previous", previousHandler)
+	c.OnCommand("prev", previousHandler)
 	c.OnCommand("seek", seekHandler)
+	c.OnCommand("rewind", rewindHandler)
+	c.OnCommand("replay", replayHandler)
+	c.OnCommand("effect", effectHandler)
+	c.OnCommand("speed", speedHandler)
+	c.OnCommand("volume", volumeHandler)
+	c.OnCommand("vmode", videoModeHandler)
+	c.OnCommand("radio", radioHandler)
+	c.OnCommand("ingest", ingestHandler)
+	c.OnCommand("record", recordHandler)
+	c.OnCommand("bridge", bridgeHandler)
+	c.OnCommand("soundboard", soundboardHandler)
+	c.OnCommand("sb", soundboardHandler)
+	c.OnCommand("247", alwaysOnHandler)
+	c.OnCommand("alwayson", alwaysOnHandler)
 	c.OnCommand("sh", shellCommand)
 	c.OnCommand("skip", skipHandler)
+	c.OnCommand("skipto", skipToHandler)
+	c.OnCommand("voteskip", voteSkipHandler)
+	c.OnCommand("shuffle", shuffleHandler)
+	c.OnCommand("move", moveHandler)
 	c.OnCommand("stop", stopHandler)
 	c.OnCommand("end", stopHandler)
 	c.OnCommand("start", startHandler)
@@ -63,6 +85,7 @@ func LoadModules(c *gotdbot.Client) {
 	c.OnCommand("fvplay", fVPlayHandler)
 	c.OnCommand("fvp", fVPlayHandler)
 	c.OnCommand("remove", removeHandler)
+	c.OnCommand("clearqueue", clearQueueHandler)
 	c.OnCommand("mute", muteHandler)
 	c.OnCommand("unmute", unmuteHandler)
 	c.OnCommand("settings", settingsHandler)
@@ -80,9 +103,14 @@ func LoadModules(c *gotdbot.Client) {
 	c.OnUpdateNewCallbackQuery(playCallbackHandler, callbackquery.Prefix("play_"))
 	c.OnUpdateNewCallbackQuery(vcPlayHandler, callbackquery.Prefix("vcplay_"))
 	c.OnUpdateNewCallbackQuery(settingsCallbackHandler, callbackquery.Prefix("settings_"))
+	c.OnUpdateNewCallbackQuery(queueCallbackHandler, callbackquery.Prefix("queue_"))
 	c.OnUpdateNewCallbackQuery(autoplayCallbackHandler, callbackquery.Equal("autoplay_toggle"))
+	c.OnUpdateNewCallbackQuery(voteSkipCallbackHandler, callbackquery.Equal("vote_skip"))
+	c.OnUpdateNewCallbackQuery(soundboardCallbackHandler, callbackquery.Prefix("sb_"))
 
 	c.OnUpdateChatMember(handleParticipant, nil)
+	watchQueueViews(c)
+	watchSkipVotes()
 	c.OnUpdateNewMessage(handleVoiceChatMessage, nil)
 
 	c.Logger.Debug("Handlers loaded successfully")
@@ -0,0 +1,59 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ashokshau/tgmusic/src/core/cache"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// moveHandler handles the /move command.
+func moveHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+
+	if !cache.ChatCache.IsActive(chatID) {
+		_, _ = m.ReplyText(c, "The bot is not streaming in the video chat.", nil)
+		return nil
+	}
+
+	parts := strings.Fields(Args(m))
+	if len(parts) != 2 {
+		_, _ = m.ReplyText(c, "<b>Usage:</b> <code>/move [from] [to]</code>\n\nExample: <code>/move 5 1</code> (track #5 plays next)", replyOpts)
+		return nil
+	}
+
+	from, err1 := strconv.Atoi(parts[0])
+	to, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		_, _ = m.ReplyText(c, "Please provide valid track numbers.", nil)
+		return nil
+	}
+
+	queueLen := cache.ChatCache.GetQueueLength(chatID)
+	if queueLen <= 1 {
+		_, _ = m.ReplyText(c, "There are no upcoming tracks.", nil)
+		return nil
+	}
+	if !cache.ChatCache.MoveTrack(chatID, from, to) {
+		_, _ = m.ReplyText(c, fmt.Sprintf("Track numbers must be between 1 and %d.", queueLen-1), nil)
+		return nil
+	}
+
+	_, err := m.ReplyText(c, fmt.Sprintf("Track #%d has been moved to #%d by %s.", from, to, firstName(c, m)), replyOpts)
+	return err
+}
@@ -13,6 +13,7 @@ import (
 
 	"ashokshau/tgmusic/src/core"
 	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
 	"ashokshau/tgmusic/src/vc"
 
 	td "github.com/AshokShau/gotdbot"
@@ -38,7 +39,7 @@ func muteHandler(c *td.Client, m *td.Message) error {
 		return err
 	}
 
-	_, err := m.ReplyText(c, fmt.Sprintf("Playback has been muted by %s.", firstName(c, m)), &td.SendTextMessageOpts{ReplyMarkup: core.ControlButtons("mute")})
+	_, err := m.ReplyText(c, fmt.Sprintf("Playback has been muted by %s.", firstName(c, m)), &td.SendTextMessageOpts{ReplyMarkup: core.ControlButtons("mute", db.Instance.GetVolume(chatID))})
 	return err
 }
 
@@ -61,7 +62,10 @@ func unmuteHandler(c *td.Client, m *td.Message) error {
 		_, err = m.ReplyText(c, fmt.Sprintf("Failed to unmute the playback: %s", err.Error()), nil)
 		return err
 	}
+	if err := vc.Calls.ApplyPendingRestart(c, chatID); err != nil {
+		c.Logger.Warn("Failed to restart stream", "chatID", chatID, "error", err)
+	}
 
-	_, err := m.ReplyText(c, fmt.Sprintf("Playback has been unmuted by %s.", firstName(c, m)), &td.SendTextMessageOpts{ReplyMarkup: core.ControlButtons("unmute")})
+	_, err := m.ReplyText(c, fmt.Sprintf("Playback has been unmuted by %s.", firstName(c, m)), &td.SendTextMessageOpts{ReplyMarkup: core.ControlButtons("unmute", db.Instance.GetVolume(chatID))})
 	return err
 }
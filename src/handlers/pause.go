@@ -13,6 +13,7 @@ import (
 
 	"ashokshau/tgmusic/src/core"
 	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
 	"ashokshau/tgmusic/src/vc"
 
 	td "github.com/AshokShau/gotdbot"
@@ -35,7 +36,7 @@ func pauseHandler(c *td.Client, m *td.Message) error {
 		return nil
 	}
 
-	_, err := m.ReplyText(c, fmt.Sprintf("Playback has been paused by %s.", firstName(c, m)), &td.SendTextMessageOpts{ReplyMarkup: core.ControlButtons("pause")})
+	_, err := m.ReplyText(c, fmt.Sprintf("Playback has been paused by %s.", firstName(c, m)), &td.SendTextMessageOpts{ReplyMarkup: core.ControlButtons("pause", db.Instance.GetVolume(chatID))})
 	return err
 }
 
@@ -60,7 +61,10 @@ func resumeHandler(c *td.Client, m *td.Message) error {
 		_, _ = m.ReplyText(c, fmt.Sprintf("Failed to resume the playback: %s", err.Error()), nil)
 		return nil
 	}
+	if err := vc.Calls.ApplyPendingRestart(c, chatID); err != nil {
+		c.Logger.Warn("Failed to restart stream", "chatID", chatID, "error", err)
+	}
 
-	_, err := m.ReplyText(c, fmt.Sprintf("Playback has been resumed by %s.", firstName(c, m)), &td.SendTextMessageOpts{ReplyMarkup: core.ControlButtons("resume")})
+	_, err := m.ReplyText(c, fmt.Sprintf("Playback has been resumed by %s.", firstName(c, m)), &td.SendTextMessageOpts{ReplyMarkup: core.ControlButtons("resume", db.Instance.GetVolume(chatID))})
 	return err
 }
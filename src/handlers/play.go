@@ -15,6 +15,7 @@ import (
 	"ashokshau/tgmusic/src/core/db"
 	"ashokshau/tgmusic/src/core/dl"
 	"ashokshau/tgmusic/src/vc"
+	"context"
 	"fmt"
 	"html"
 	"strings"
@@ -71,8 +72,8 @@ func fVPlayHandler(c *td.Client, m *td.Message) error {
 func handlePlay(c *td.Client, m *td.Message, isVideo bool, force bool) error {
 	chatID := m.ChatId
 
-	if queueLen := cache.ChatCache.GetQueueLength(chatID); queueLen > 10 {
-		_, _ = m.ReplyText(c, "Queue is full (max 10 tracks). Use /end to clear.", nil)
+	if limit := db.Instance.GetQueueLimit(chatID); cache.ChatCache.GetQueueLength(chatID) >= limit {
+		_, _ = m.ReplyText(c, fmt.Sprintf("Queue is full (max %d tracks). Use /end to clear.", limit), nil)
 		return td.EndGroups
 	}
 
@@ -188,11 +189,6 @@ func handleMedia(c *td.Client, m *td.Message, updater *td.Message, dlMsg *td.Mes
 	}
 
 	fileId := dlMsg.RemoteFileID()
-	if _track := cache.ChatCache.GetTrackIfExists(chatId, fileId); _track != nil {
-		_, err := updater.EditText(c, "Track already in queue or playing.", nil)
-		return err
-	}
-
 	dur := utils.GetFileDur(dlMsg)
 	link, err := dlMsg.GetLink(c)
 	if err != nil {
@@ -201,20 +197,25 @@ func handleMedia(c *td.Client, m *td.Message, updater *td.Message, dlMsg *td.Mes
 	}
 
 	saveCache := utils.CachedTrack{
-		URL: link.Link, Name: fileName, User: firstName(c, m), TrackID: fileId,
+		URL: link.Link, Name: fileName, User: firstName(c, m), UserID: m.SenderID(), TrackID: fileId,
 		Duration: dur, IsVideo: isVideo, Platform: utils.Telegram,
 	}
 
-	var qLen int
-	if force {
-		qLen = cache.ChatCache.AddSongToFront(chatId, &saveCache)
-	} else {
-		qLen = cache.ChatCache.AddSong(chatId, &saveCache)
+	ok, dupNotice := checkDuplicate(chatId, &saveCache)
+	if !ok {
+		_, err := updater.EditText(c, dupNotice, nil)
+		return err
+	}
+
+	added, qLen, reason := queueTracks(chatId, []*utils.CachedTrack{&saveCache}, force)
+	if len(added) == 0 {
+		_, err := updater.EditText(c, reason, nil)
+		return err
 	}
 
 	if qLen > 1 {
 		if force {
-			_ = vc.Calls.PlayNext(c, chatId)
+			_ = vc.Calls.Skip(c, chatId)
 			_ = c.DeleteMessages(chatId, []int64{updater.Id}, &td.DeleteMessagesOpts{Revoke: true})
 			return nil
 		}
@@ -222,9 +223,12 @@ func handleMedia(c *td.Client, m *td.Message, updater *td.Message, dlMsg *td.Mes
 		escName := html.EscapeString(saveCache.Name)
 		escUser := html.EscapeString(saveCache.User)
 		queueInfo := fmt.Sprintf(
-			"<u><b>Added to queue: %d</b></u>\n\n<b>Title:</b> <a href='%s'>%s</a>\n\n<b>Duration:</b> %s min\n<b>Requested by:</b> %s",
-			qLen, escURL, escName, utils.SecToMin(saveCache.Duration), escUser,
+			"<u><b>Added to queue: %d</b></u>\n\n<b>Title:</b> <a href='%s'>%s</a>\n\n<b>Duration:</b> %s\n<b>Requested by:</b> %s",
+			qLen, escURL, escName, utils.TrackDuration(&saveCache), escUser,
 		)
+		if dupNotice != "" {
+			queueInfo += "\n\n<i>" + html.EscapeString(dupNotice) + "</i>"
+		}
 		_, err := updater.EditText(c, queueInfo, &td.EditTextMessageOpts{ReplyMarkup: core.QueueMarkup(saveCache.TrackID), ParseMode: "HTML", DisableWebPagePreview: true})
 		return err
 	}
@@ -240,32 +244,19 @@ func handleMedia(c *td.Client, m *td.Message, updater *td.Message, dlMsg *td.Mes
 	if dur == 0 {
 		dur = utils.GetMediaDuration(filePath)
 		saveCache.Duration = dur
+		cache.ChatCache.SetTrackDuration(chatId, saveCache.TrackID, dur)
 	}
 
 	saveCache.FilePath = filePath
+	cache.ChatCache.SetTrackFile(chatId, saveCache.TrackID, filePath)
 
-	if err = vc.Calls.PlayMedia(c, chatId, saveCache.FilePath, saveCache.IsVideo, ""); err != nil {
+	if err = vc.Calls.PlayMedia(c, chatId, saveCache.FilePath, saveCache.IsVideo); err != nil {
 		cache.ChatCache.RemoveCurrentSong(chatId)
 		_, err = updater.EditText(c, err.Error(), &td.EditTextMessageOpts{ParseMode: "HTML", DisableWebPagePreview: true})
 		return err
 	}
 
-	escURL := html.EscapeString(saveCache.URL)
-	escName := html.EscapeString(saveCache.Name)
-	escUser := html.EscapeString(saveCache.User)
-
-	nowPlaying := fmt.Sprintf(
-		"<u><b>| Started streaming</b></u>\n\n<b>Title:</b> <a href='%s'>%s</a>\n\n<b>Duration:</b> %s min\n<b>Requested by:</b> %s",
-		escURL, escName, utils.SecToMin(saveCache.Duration), escUser,
-	)
-
-	_, err = updater.EditText(c, nowPlaying, &td.EditTextMessageOpts{
-		ParseMode:             "HTML",
-		ReplyMarkup:           core.ControlButtons("play"),
-		DisableWebPagePreview: true,
-	})
-
-	return err
+	return vc.Calls.ShowNowPlaying(c, chatId, updater)
 }
 
 // handleTextSearch handles a text search for a song.
@@ -282,11 +273,6 @@ func handleTextSearch(c *td.Client, m *td.Message, updater *td.Message, wrapper
 	}
 
 	song := searchResult.Results[0]
-	if _track := cache.ChatCache.GetTrackIfExists(chatId, song.Id); _track != nil {
-		_, err := updater.EditText(c, "Track already in queue or playing.", nil)
-		return err
-	}
-
 	return handleSingleTrack(c, m, updater, song, "", chatId, isVideo, force)
 }
 
@@ -294,10 +280,6 @@ func handleTextSearch(c *td.Client, m *td.Message, updater *td.Message, wrapper
 func handleUrl(c *td.Client, m *td.Message, updater *td.Message, trackInfo utils.PlatformTracks, chatId int64, isVideo bool, force bool) error {
 	if len(trackInfo.Results) == 1 {
 		track := trackInfo.Results[0]
-		if _track := cache.ChatCache.GetTrackIfExists(chatId, track.Id); _track != nil {
-			_, err := updater.EditText(c, "Track already in queue or playing.", nil)
-			return err
-		}
 		return handleSingleTrack(c, m, updater, track, "", chatId, isVideo, force)
 	}
 
@@ -306,27 +288,32 @@ func handleUrl(c *td.Client, m *td.Message, updater *td.Message, trackInfo utils
 
 // handleSingleTrack handles a single track.
 func handleSingleTrack(c *td.Client, m *td.Message, updater *td.Message, song utils.MusicTrack, filePath string, chatId int64, isVideo bool, force bool) error {
-	if song.Duration > int(config.SongDurationLimit) {
+	if !song.IsLive && song.Duration > int(config.SongDurationLimit) {
 		_, err := updater.EditText(c, fmt.Sprintf("Sorry, song exceeds max duration of %d minutes.", config.SongDurationLimit/60), nil)
 		return err
 	}
 
 	saveCache := utils.CachedTrack{
-		URL: song.Url, Name: song.Title, User: firstName(c, m), FilePath: filePath,
+		URL: dl.CanonicalURL(song.Url), Name: song.Title, User: firstName(c, m), UserID: m.SenderID(), FilePath: filePath,
 		Thumbnail: song.Thumbnail, TrackID: song.Id, Duration: song.Duration, Channel: song.Channel, Views: song.Views,
-		IsVideo: isVideo, Platform: song.Platform,
+		IsVideo: isVideo, Platform: song.Platform, IsLive: song.IsLive,
+	}
+
+	ok, dupNotice := checkDuplicate(chatId, &saveCache)
+	if !ok {
+		_, err := updater.EditText(c, dupNotice, nil)
+		return err
 	}
 
-	var qLen int
-	if force {
-		qLen = cache.ChatCache.AddSongToFront(chatId, &saveCache)
-	} else {
-		qLen = cache.ChatCache.AddSong(chatId, &saveCache)
+	added, qLen, reason := queueTracks(chatId, []*utils.CachedTrack{&saveCache}, force)
+	if len(added) == 0 {
+		_, err := updater.EditText(c, reason, nil)
+		return err
 	}
 
 	if qLen > 1 {
 		if force {
-			_ = vc.Calls.PlayNext(c, chatId)
+			_ = vc.Calls.Skip(c, chatId)
 			_ = c.DeleteMessages(chatId, []int64{updater.Id}, &td.DeleteMessagesOpts{Revoke: true})
 			return nil
 		}
@@ -334,47 +321,48 @@ func handleSingleTrack(c *td.Client, m *td.Message, updater *td.Message, song ut
 		escName := html.EscapeString(saveCache.Name)
 		escUser := html.EscapeString(saveCache.User)
 		queueInfo := fmt.Sprintf(
-			"<u><b>Added to queue: %d</b></u>\n\n<b>Title:</b> <a href='%s'>%s</a>\n\n<b>Duration:</b> %s min\n<b>Requested by:</b> %s",
-			qLen, escURL, escName, utils.SecToMin(saveCache.Duration), escUser,
+			"<u><b>Added to queue: %d</b></u>\n\n<b>Title:</b> <a href='%s'>%s</a>\n\n<b>Duration:</b> %s\n<b>Requested by:</b> %s",
+			qLen, escURL, escName, utils.TrackDuration(&saveCache), escUser,
 		)
+		if dupNotice != "" {
+			queueInfo += "\n\n<i>" + html.EscapeString(dupNotice) + "</i>"
+		}
 
 		_, err := updater.EditText(c, queueInfo, &td.EditTextMessageOpts{ReplyMarkup: core.QueueMarkup(saveCache.TrackID), ParseMode: "HTML", DisableWebPagePreview: true})
 		return err
 	}
 
-	if saveCache.FilePath == "" {
-		dlResult, err := dl.DownloadCachedTrack(&saveCache, c)
+	playPath := saveCache.FilePath
+	switch {
+	case saveCache.Platform == utils.Ingest:
+		// The listener URL carries the stream key, so it is resolved here and not kept with the track.
+		listenURL, _, err := vc.Calls.ReserveIngest(chatId)
+		if err != nil {
+			cache.ChatCache.RemoveCurrentSong(chatId)
+			_, err = updater.EditText(c, fmt.Sprintf("❌ %s", err.Error()), nil)
+			return err
+		}
+		playPath = listenURL
+	case playPath == "":
+		saveCache.Quality = db.Instance.GetQuality(chatId)
+		dlResult, err := dl.DownloadCachedTrack(context.Background(), &saveCache, c)
 		if err != nil {
 			cache.ChatCache.RemoveCurrentSong(chatId)
 			_, err = updater.EditText(c, fmt.Sprintf("Download failed: %s", err.Error()), nil)
 			return err
 		}
 
-		saveCache.FilePath = dlResult
+		playPath = dlResult
+		cache.ChatCache.SetTrackFile(chatId, saveCache.TrackID, dlResult)
 	}
 
-	if err := vc.Calls.PlayMedia(c, chatId, saveCache.FilePath, saveCache.IsVideo, ""); err != nil {
+	if err := vc.Calls.PlayMedia(c, chatId, playPath, saveCache.IsVideo); err != nil {
 		cache.ChatCache.RemoveCurrentSong(chatId)
 		_, err = updater.EditText(c, err.Error(), &td.EditTextMessageOpts{ParseMode: "HTML", DisableWebPagePreview: true})
 		return err
 	}
 
-	escURLnp := html.EscapeString(saveCache.URL)
-	escNamenp := html.EscapeString(saveCache.Name)
-	escUsernp := html.EscapeString(saveCache.User)
-
-	nowPlaying := fmt.Sprintf(
-		"<u><b>| Started streaming</b></u>\n\n<b>Title:</b> <a href='%s'>%s</a>\n\n<b>Duration:</b> %s min\n<b>Requested by:</b> %s",
-		escURLnp, escNamenp, utils.SecToMin(song.Duration), escUsernp,
-	)
-
-	_, err := updater.EditText(c, nowPlaying, &td.EditTextMessageOpts{
-		ReplyMarkup:           core.ControlButtons("play"),
-		ParseMode:             "HTML",
-		DisableWebPagePreview: true,
-	})
-
-	if err != nil {
+	if err := vc.Calls.ShowNowPlaying(c, chatId, updater); err != nil {
 		c.Logger.Warn("Edit message failed", "error", err)
 		return err
 	}
@@ -397,15 +385,16 @@ func handleMultipleTracks(c *td.Client, m *td.Message, updater *td.Message, trac
 	var firstTrack *utils.CachedTrack
 
 	for _, track := range tracks {
-		if track.Duration > int(config.SongDurationLimit) {
+		if !track.IsLive && track.Duration > int(config.SongDurationLimit) {
 			skippedTracks = append(skippedTracks, track.Title)
 			continue
 		}
 
 		saveCache := &utils.CachedTrack{
 			Name: track.Title, TrackID: track.Id, Duration: track.Duration,
-			Thumbnail: track.Thumbnail, User: firstName(c, m), Platform: track.Platform,
-			IsVideo: isVideo, URL: track.Url, Channel: track.Channel, Views: track.Views,
+			Thumbnail: track.Thumbnail, User: firstName(c, m), UserID: m.SenderID(), Platform: track.Platform,
+			IsVideo: isVideo, URL: dl.CanonicalURL(track.Url), Channel: track.Channel, Views: track.Views,
+			IsLive: track.IsLive,
 		}
 		tracksToAdd = append(tracksToAdd, saveCache)
 	}
@@ -419,29 +408,32 @@ func handleMultipleTracks(c *td.Client, m *td.Message, updater *td.Message, trac
 		return err
 	}
 
-	var qLenAfter int
-	var startLen int
+	tracksToAdd, dupSkipped := dropDuplicates(chatId, tracksToAdd)
+	if len(tracksToAdd) == 0 {
+		_, err := updater.EditText(c, "All tracks are already in the queue.", nil)
+		return err
+	}
+
+	beforeLimits := len(tracksToAdd)
+	tracksToAdd, qLenAfter, limitReason := queueTracks(chatId, tracksToAdd, force)
+	if len(tracksToAdd) == 0 {
+		_, err := updater.EditText(c, limitReason, nil)
+		return err
+	}
+	limitSkipped := beforeLimits - len(tracksToAdd)
 
-	if force {
-		qLenAfter = 0
-		for i := len(tracksToAdd) - 1; i >= 0; i-- {
-			qLenAfter = cache.ChatCache.AddSongToFront(chatId, tracksToAdd[i])
-		}
-		startLen = qLenAfter - len(tracksToAdd)
-		if startLen > 0 {
-			_ = vc.Calls.PlayNext(c, chatId)
-			_ = c.DeleteMessages(chatId, []int64{updater.Id}, &td.DeleteMessagesOpts{Revoke: true})
-			return nil
-		}
-	} else {
-		qLenAfter = cache.ChatCache.AddSongs(chatId, tracksToAdd)
-		startLen = qLenAfter - len(tracksToAdd)
+	startLen := qLenAfter - len(tracksToAdd)
+	if force && startLen > 0 {
+		_ = vc.Calls.Skip(c, chatId)
+		_ = c.DeleteMessages(chatId, []int64{updater.Id}, &td.DeleteMessagesOpts{Revoke: true})
+		return nil
 	}
 
 	if startLen == 0 {
 		shouldPlayFirst = true
 		firstTrack = tracksToAdd[0]
-		firstTrack.Loop = 1
+		// Set through the store rather than the track, which may be a copy of the queued one.
+		cache.ChatCache.SetLoopCount(chatId, 1)
 	}
 
 	var sb strings.Builder
@@ -467,6 +459,12 @@ func handleMultipleTracks(c *td.Client, m *td.Message, updater *td.Message, trac
 	if len(skippedTracks) > 0 {
 		fmt.Fprintf(&sb, "\n\n<b>Skipped %d tracks</b> (exceeded duration limit).", len(skippedTracks))
 	}
+	if dupSkipped > 0 {
+		fmt.Fprintf(&sb, "\n\n<b>Skipped %d tracks</b> (already in the queue).", dupSkipped)
+	}
+	if limitSkipped > 0 {
+		fmt.Fprintf(&sb, "\n\n<b>Skipped %d tracks:</b> %s", limitSkipped, html.EscapeString(limitReason))
+	}
 
 	fullMessage := sb.String()
 
@@ -11,17 +11,38 @@ package handlers
 import (
 	"ashokshau/tgmusic/src/utils"
 	"fmt"
+	"html"
 	"math"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"ashokshau/tgmusic/src/core"
 	"ashokshau/tgmusic/src/core/cache"
 	"ashokshau/tgmusic/src/vc"
 
 	td "github.com/AshokShau/gotdbot"
 )
 
-// queueHandler displays the current playback queue with detailed information.
+const (
+	queuePageSize     = 5
+	queueRefreshDelay = 3 * time.Second
+)
+
+// queueView is an interactive queue message that is refreshed when the queue changes.
+type queueView struct {
+	msg     *td.Message
+	page    int
+	pending bool
+}
+
+var (
+	queueViewsMu sync.Mutex
+	queueViews   = make(map[int64]*queueView)
+)
+
+// queueHandler displays the current playback queue as an interactive, paginated message.
 func queueHandler(c *td.Client, m *td.Message) error {
 	if !adminMode(c, m) {
 		return td.EndGroups
@@ -29,39 +50,124 @@ func queueHandler(c *td.Client, m *td.Message) error {
 
 	chatID := m.ChatId
 
-	chat, err := c.GetChat(chatID)
+	if !cache.ChatCache.IsActive(chatID) {
+		_, _ = m.ReplyText(c, "The queue is empty.", nil)
+		return nil
+	}
+
+	text, markup, page := renderQueue(c, chatID, 0)
+	msg, err := m.ReplyText(c, text, &td.SendTextMessageOpts{ReplyMarkup: markup, ParseMode: "HTML", DisableWebPagePreview: true})
 	if err != nil {
-		_, _ = m.ReplyText(c, "Error fetching chat information.", nil)
+		return err
+	}
+
+	queueViewsMu.Lock()
+	queueViews[chatID] = &queueView{msg: msg, page: page}
+	queueViewsMu.Unlock()
+	return nil
+}
+
+// queueCallbackHandler handles the page and per-track buttons of the queue browser.
+func queueCallbackHandler(c *td.Client, cb *td.UpdateNewCallbackQuery) error {
+	if !adminModeCB(c, cb) {
+		return td.EndGroups
+	}
+
+	chatID := cb.ChatId
+	parts := strings.Split(cb.DataString(), "_")
+	if len(parts) < 3 {
 		return nil
 	}
 
+	action := parts[1]
+	page, _ := strconv.Atoi(parts[len(parts)-1])
+
+	if action != "page" {
+		if len(parts) < 5 {
+			return nil
+		}
+		index, err := strconv.Atoi(parts[2])
+		queue := cache.ChatCache.GetQueue(chatID)
+		if err != nil || index < 1 || index >= len(queue) || core.QueueTrackKey(queue[index].TrackID) != parts[3] {
+			_ = cb.Answer(c, 0, false, "This track is no longer in the queue.", "")
+		} else {
+			answer := queueAction(c, chatID, action, index, queue[index])
+			_ = cb.Answer(c, 0, false, answer, "")
+		}
+	} else {
+		_ = cb.Answer(c, 0, false, "", "")
+	}
+
+	text, markup, page := renderQueue(c, chatID, page)
+	_, _ = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: markup, ParseMode: "HTML", DisableWebPagePreview: true})
+
+	queueViewsMu.Lock()
+	if view, ok := queueViews[chatID]; ok && view.msg.Id == cb.MessageId {
+		view.page = page
+	}
+	queueViewsMu.Unlock()
+	return nil
+}
+
+// queueAction applies a queue browser button to the track at index and returns the text to answer with.
+func queueAction(c *td.Client, chatID int64, action string, index int, track *utils.CachedTrack) string {
+	switch action {
+	case "play":
+		if index > 1 && !cache.ChatCache.MoveTrack(chatID, index, 1) {
+			return "Track not found in queue."
+		}
+		if err := vc.Calls.Skip(c, chatID); err != nil {
+			return "Unable to play the track."
+		}
+		return "Playing now."
+	case "up":
+		if index == 1 {
+			return "This track is already up next."
+		}
+		if !cache.ChatCache.MoveTrack(chatID, index, index-1) {
+			return "Unable to move the track."
+		}
+		return fmt.Sprintf("Moved to #%d.", index-1)
+	case "rm":
+		if !cache.ChatCache.RemoveTrack(chatID, index) {
+			return "Unable to remove the track."
+		}
+		return fmt.Sprintf("Removed %s.", truncate(track.Name, 40))
+	}
+	return "Unknown action."
+}
+
+// renderQueue builds the text and keyboard for a page of the queue browser. The page is
+// clamped to the available range and returned alongside. The keyboard is nil when the
+// queue is empty.
+func renderQueue(c *td.Client, chatID int64, page int) (string, *td.ReplyMarkupInlineKeyboard, int) {
 	queue := cache.ChatCache.GetQueue(chatID)
 	if len(queue) == 0 {
-		_, _ = m.ReplyText(c, "The queue is empty.", nil)
-		return nil
+		return "The queue is empty.", nil, 0
 	}
 
-	if !cache.ChatCache.IsActive(chatID) {
-		_, _ = m.ReplyText(c, "The bot is not streaming in the video chat.", nil)
-		return nil
+	title := "this chat"
+	if chat, err := c.GetChat(chatID); err == nil {
+		title = chat.Title
 	}
 
 	current := queue[0]
 	playedTime, _ := vc.Calls.PlayedTime(chatID)
 
 	var b strings.Builder
-	b.WriteString(fmt.Sprintf("<b>Queue for %s</b>\n\n", chat.Title))
+	b.WriteString(fmt.Sprintf("<b>Queue for %s</b>\n\n", html.EscapeString(title)))
 
 	b.WriteString("<b>Now Playing:</b>\n")
-	b.WriteString(fmt.Sprintf("• <b>Title:</b> <code>%s</code>\n", truncate(current.Name, 45)))
-	b.WriteString(fmt.Sprintf("• <b>By:</b> %s\n", current.User))
-	b.WriteString(fmt.Sprintf("• <b>Duration:</b> %s min\n", utils.SecToMin(current.Duration)))
+	b.WriteString(fmt.Sprintf("• <b>Title:</b> <code>%s</code>\n", html.EscapeString(truncate(current.Name, 45))))
+	b.WriteString(fmt.Sprintf("• <b>By:</b> %s\n", html.EscapeString(current.User)))
+	b.WriteString(fmt.Sprintf("• <b>Duration:</b> %s\n", utils.TrackDuration(current)))
 	b.WriteString("• <b>Loop:</b> ")
 	if current.Loop > 0 {
 		b.WriteString("On\n")
 	} else {
 		b.WriteString("Off\n")
 	}
+	b.WriteString(fmt.Sprintf("• <b>Repeat:</b> %s\n", cache.ChatCache.GetRepeatMode(chatID)))
 	b.WriteString("• <b>Progress:</b> ")
 	if playedTime > 0 && playedTime < math.MaxInt {
 		b.WriteString(utils.SecToMin(int(playedTime)))
@@ -70,46 +176,73 @@ func queueHandler(c *td.Client, m *td.Message) error {
 	}
 	b.WriteString(" min\n")
 
-	if len(queue) > 1 {
-		b.WriteString(fmt.Sprintf("\n<b>Next Up (%d):</b>\n", len(queue)-1))
+	upcoming := len(queue) - 1
+	pages := (upcoming + queuePageSize - 1) / queuePageSize
+	if pages == 0 {
+		pages = 1
+	}
+	page = max(0, min(page, pages-1))
+
+	start := 1 + page*queuePageSize
+	var shown []*utils.CachedTrack
+	if upcoming > 0 {
+		b.WriteString(fmt.Sprintf("\n<b>Next Up (%d):</b>\n", upcoming))
 
-		for i, song := range queue[1:] {
-			if i >= 14 {
-				break
-			}
-			b.WriteString(strconv.Itoa(i + 1))
+		end := min(start+queuePageSize, len(queue))
+		for i := start; i < end; i++ {
+			song := queue[i]
+			b.WriteString(strconv.Itoa(i))
 			b.WriteString(". <code>")
-			b.WriteString(truncate(song.Name, 45))
+			b.WriteString(html.EscapeString(truncate(song.Name, 45)))
 			b.WriteString("</code> | ")
-			b.WriteString(utils.SecToMin(song.Duration))
-			b.WriteString(" min\n")
-		}
-
-		if len(queue) > 15 {
-			b.WriteString(fmt.Sprintf("...and %d more tracks\n", len(queue)-15))
+			b.WriteString(utils.TrackDuration(song))
+			b.WriteString("\n")
+			shown = append(shown, song)
 		}
 	}
 
 	b.WriteString(fmt.Sprintf("\n<b>Total:</b> %d tracks", len(queue)))
+	return b.String(), core.QueueKeyboard(page, pages, start, shown), page
+}
 
-	text := b.String()
-	if len(text) > 4096 {
-		var sb strings.Builder
-		progress := "0:00"
-		if playedTime > 0 && playedTime < math.MaxInt {
-			progress = utils.SecToMin(int(playedTime))
+// watchQueueViews refreshes open queue messages shortly after their chat's queue changes.
+// Refreshes are delayed so a burst of changes results in a single edit.
+func watchQueueViews(c *td.Client) {
+	cache.ChatCache.OnChange(func(chatID int64) {
+		queueViewsMu.Lock()
+		defer queueViewsMu.Unlock()
+
+		view, ok := queueViews[chatID]
+		if !ok || view.pending {
+			return
+		}
+		view.pending = true
+		time.AfterFunc(queueRefreshDelay, func() {
+			refreshQueueView(c, chatID)
+		})
+	})
+}
+
+// refreshQueueView re-renders the open queue message of a chat. Views whose queue has
+// ended or whose message can no longer be edited are dropped.
+func refreshQueueView(c *td.Client, chatID int64) {
+	queueViewsMu.Lock()
+	view, ok := queueViews[chatID]
+	if !ok {
+		queueViewsMu.Unlock()
+		return
+	}
+	view.pending = false
+	msg, page := view.msg, view.page
+	queueViewsMu.Unlock()
+
+	text, markup, _ := renderQueue(c, chatID, page)
+	_, err := msg.EditText(c, text, &td.EditTextMessageOpts{ReplyMarkup: markup, ParseMode: "HTML", DisableWebPagePreview: true})
+	if markup == nil || (err != nil && !strings.Contains(err.Error(), "not modified")) {
+		queueViewsMu.Lock()
+		if queueViews[chatID] == view {
+			delete(queueViews, chatID)
 		}
-		sb.WriteString(fmt.Sprintf(
-			"<b>Queue for %s</b>\n\n<b>Now Playing:</b>\n• <code>%s</code>\n• %s/%s min\n\n<b>Total:</b> %d tracks",
-			chat.Title,
-			truncate(current.Name, 45),
-			progress,
-			utils.SecToMin(current.Duration),
-			len(queue),
-		))
-		text = sb.String()
-	}
-
-	_, err = m.ReplyText(c, text, replyOpts)
-	return err
+		queueViewsMu.Unlock()
+	}
 }
@@ -0,0 +1,116 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"ashokshau/tgmusic/config"
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/core/radio"
+	"ashokshau/tgmusic/src/utils"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// findStation looks a station up by its number in the catalog, its exact name, or part of its name.
+func findStation(stations []radio.Station, query string) (radio.Station, bool) {
+	if n, err := strconv.Atoi(query); err == nil {
+		if n >= 1 && n <= len(stations) {
+			return stations[n-1], true
+		}
+		return radio.Station{}, false
+	}
+
+	query = strings.ToLower(query)
+	for _, s := range stations {
+		if strings.ToLower(s.Name) == query {
+			return s, true
+		}
+	}
+	for _, s := range stations {
+		if strings.Contains(strings.ToLower(s.Name), query) {
+			return s, true
+		}
+	}
+	return radio.Station{}, false
+}
+
+// radioHandler handles the /radio command, which lists the station catalog or queues a
+// station by number, name or stream URL.
+func radioHandler(c *td.Client, m *td.Message) error {
+	if !playMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+	stations, err := radio.LoadCatalog(config.RadioCatalog)
+	if err != nil {
+		c.Logger.Warn("failed to load the radio catalog", "error", err)
+		_, _ = m.ReplyText(c, "The radio catalog is unavailable.", nil)
+		return nil
+	}
+
+	args := Args(m)
+	if args == "" {
+		var b strings.Builder
+		b.WriteString("<b>📻 Radio Stations</b>\n\n")
+		for i, s := range stations {
+			fmt.Fprintf(&b, "<b>%d.</b> %s\n", i+1, html.EscapeString(s.Name))
+		}
+		b.WriteString("\n<b>Usage:</b> <code>/radio [number|name|stream URL]</code>")
+		_, err = m.ReplyText(c, b.String(), replyOpts)
+		return err
+	}
+
+	var station radio.Station
+	if strings.HasPrefix(args, "http://") || strings.HasPrefix(args, "https://") {
+		station = radio.Station{Name: args, URL: args}
+	} else if s, ok := findStation(stations, args); ok {
+		station = s
+	} else {
+		_, err = m.ReplyText(c, "Station not found. Use /radio to see the list.", nil)
+		return err
+	}
+
+	if limit := db.Instance.GetQueueLimit(chatID); cache.ChatCache.GetQueueLength(chatID) >= limit {
+		_, _ = m.ReplyText(c, fmt.Sprintf("Queue is full (max %d tracks). Use /end to clear.", limit), nil)
+		return td.EndGroups
+	}
+
+	updater, err := m.ReplyText(c, "📻 Tuning in...", nil)
+	if err != nil {
+		c.Logger.Warn("failed to send message", "error", err)
+		return td.EndGroups
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	streamURL, err := radio.ResolveStream(ctx, station.URL)
+	cancel()
+	if err != nil {
+		_, _ = updater.EditText(c, fmt.Sprintf("❌ Failed to tune in: %s", err.Error()), nil)
+		return nil
+	}
+
+	track := utils.MusicTrack{
+		Title:    station.Name,
+		Id:       streamURL,
+		Url:      streamURL,
+		Channel:  "Radio",
+		Platform: utils.DirectLink,
+		IsLive:   true,
+	}
+	return handleSingleTrack(c, m, updater, track, streamURL, chatID, false, false)
+}
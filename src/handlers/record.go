@@ -0,0 +1,90 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"ashokshau/tgmusic/config"
+	"ashokshau/tgmusic/src/utils"
+	"ashokshau/tgmusic/src/vc"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// recordHandler handles the /record command, which records the voice chat to an audio file
+// that is uploaded when the recording stops. Only chat administrators may start or stop a
+// recording, whatever the chat's admin mode.
+func recordHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+	args := strings.Fields(strings.ToLower(Args(m)))
+	action := ""
+	if len(args) > 0 {
+		action = args[0]
+	}
+	if (action == "start" || action == "stop") && !isChatAdmin(c, chatID, m.SenderID()) {
+		_, _ = m.ReplyText(c, "You must be an administrator to record the voice chat.", nil)
+		return td.EndGroups
+	}
+
+	switch action {
+	case "start":
+		mode := vc.RecordCall
+		if len(args) > 1 && args[1] == vc.RecordBot {
+			mode = vc.RecordBot
+		}
+		if vc.Calls.IsRecording(chatID) {
+			_, err := m.ReplyText(c, "The voice chat is already being recorded. Use <code>/record stop</code> to end it.", replyOpts)
+			return err
+		}
+
+		what := "The voice chat"
+		if mode == vc.RecordBot {
+			what = "The bot's playback"
+		}
+		where := "posted here"
+		if config.RecordUpload == "logger" && config.LoggerId != 0 {
+			where = "sent to the bot's log"
+		}
+		notice, err := m.ReplyText(c, fmt.Sprintf(
+			"🔴 <b>Recording</b>\n\n%s is now being recorded, started by %s. The recording will be %s when it stops.\n<b>Limit:</b> %s or %d MB",
+			what, html.EscapeString(firstName(c, m)), where, utils.SecToMin(int(config.RecordMaxDuration)), config.RecordMaxSize/(1024*1024)), replyOpts)
+		if err != nil {
+			c.Logger.Warn("failed to send message", "error", err)
+			return td.EndGroups
+		}
+
+		if err = vc.Calls.StartRecording(c, chatID, mode, notice); err != nil {
+			_, _ = notice.EditText(c, fmt.Sprintf("❌ Failed to start recording: %s", err.Error()), nil)
+		}
+		return nil
+
+	case "stop":
+		if err := vc.Calls.StopRecording(chatID, "stopped by "+firstName(c, m)); err != nil {
+			_, err = m.ReplyText(c, "The voice chat is not being recorded.", nil)
+			return err
+		}
+		_, err := m.ReplyText(c, "⏹ Recording stopped. Uploading the file...", nil)
+		return err
+	}
+
+	status := "Off"
+	if vc.Calls.IsRecording(chatID) {
+		status = "🔴 Recording"
+	}
+	_, err := m.ReplyText(c, fmt.Sprintf("<b>Recording:</b> %s\n\n<b>Usage:</b> <code>/record start [bot]</code> or <code>/record stop</code>\n"+
+		"Add <code>bot</code> to record only what the bot plays instead of the whole voice chat.", status), replyOpts)
+	return err
+}
@@ -112,3 +112,30 @@ func removeHandler(c *td.Client, m *td.Message) error {
 
 	return err
 }
+
+// clearQueueHandler handles the /clearqueue command.
+func clearQueueHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+
+	if !cache.ChatCache.IsActive(chatID) {
+		_, _ = m.ReplyText(c, "The bot is not streaming in the video chat.", nil)
+		return nil
+	}
+
+	n := cache.ChatCache.ClearQueue(chatID)
+	if n == 0 {
+		_, _ = m.ReplyText(c, "There are no upcoming tracks to clear.", nil)
+		return nil
+	}
+
+	text := fmt.Sprintf("%d upcoming tracks have been cleared by %s.", n, firstName(c, m))
+	if cache.ChatCache.GetRepeatMode(chatID) == cache.RepeatQueue {
+		text += " Only the playing track is left to repeat."
+	}
+	_, err := m.ReplyText(c, text, replyOpts)
+	return err
+}
@@ -0,0 +1,51 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// repeatHandler handles the /repeat command. The mode is saved for the chat, so it can be
+// set before playback starts and lasts until it is changed again.
+func repeatHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+	args := strings.ToLower(Args(m))
+	if args == "" {
+		text := fmt.Sprintf("<b>Repeat Mode:</b> %s\n\n<b>Usage:</b> <code>/repeat [off|track|queue]</code>\n<b>off</b> - finished tracks are removed\n<b>track</b> - the current track repeats until skipped\n<b>queue</b> - finished tracks are added back to the end of the queue", db.Instance.GetRepeatMode(chatID))
+		_, err := m.ReplyText(c, text, replyOpts)
+		return err
+	}
+
+	mode := cache.RepeatMode(args)
+	switch mode {
+	case cache.RepeatOff, cache.RepeatTrack, cache.RepeatQueue:
+	default:
+		_, err := m.ReplyText(c, "Invalid repeat mode. Use off, track or queue.", nil)
+		return err
+	}
+
+	if err := db.Instance.SetRepeatMode(chatID, mode); err != nil {
+		_, err = m.ReplyText(c, "Failed to save the repeat mode.", nil)
+		return err
+	}
+	cache.ChatCache.SetRepeatMode(chatID, mode)
+	_, err := m.ReplyText(c, fmt.Sprintf("Repeat mode has been set to %s.\nChanged by: %s", mode, firstName(c, m)), nil)
+	return err
+}
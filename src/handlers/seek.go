@@ -11,7 +11,7 @@ package handlers
 import (
 	"ashokshau/tgmusic/src/utils"
 	"fmt"
-	"strconv"
+	"strings"
 
 	"ashokshau/tgmusic/src/core/cache"
 	"ashokshau/tgmusic/src/vc"
@@ -19,65 +19,138 @@ import (
 	td "github.com/AshokShau/gotdbot"
 )
 
-// seekHandler handles the /seek command.
-func seekHandler(c *td.Client, m *td.Message) error {
-	if !adminMode(c, m) {
-		return td.EndGroups
-	}
-	chatID := m.ChatId
+// defaultRewind is how far /rewind goes back when no duration is given, in seconds.
+const defaultRewind = 10
 
+// seekableTrack returns the playing track of a chat if it can be seeked, replying with the
+// reason otherwise.
+func seekableTrack(c *td.Client, m *td.Message) *utils.CachedTrack {
+	chatID := m.ChatId
 	if !cache.ChatCache.IsActive(chatID) {
-		_, err := m.ReplyText(c, "The bot is not streaming in the video chat.", nil)
-		return err
+		_, _ = m.ReplyText(c, "The bot is not streaming in the video chat.", nil)
+		return nil
 	}
 
 	playingSong := cache.ChatCache.GetPlayingTrack(chatID)
 	if playingSong == nil {
-		_, err := m.ReplyText(c, "The bot is not streaming in the video chat.", nil)
+		_, _ = m.ReplyText(c, "The bot is not streaming in the video chat.", nil)
+		return nil
+	}
+
+	if playingSong.IsLive || playingSong.Duration <= 0 {
+		_, _ = m.ReplyText(c, "Seeking is not supported for live streams.", nil)
+		return nil
+	}
+	return playingSong
+}
+
+// seekTo restarts the playing track at the given position and replies with the result.
+func seekTo(c *td.Client, m *td.Message, playingSong *utils.CachedTrack, toSeek int) error {
+	toSeek = max(toSeek, 0)
+	if toSeek >= playingSong.Duration {
+		_, err := m.ReplyText(c, fmt.Sprintf("You cannot seek beyond the track duration. Maximum allowed is %s.", utils.SecToMin(playingSong.Duration-1)), nil)
 		return err
 	}
 
+	if err := vc.Calls.SeekStream(
+		c,
+		m.ChatId,
+		playingSong.FilePath,
+		toSeek,
+		playingSong.Duration,
+		playingSong.IsVideo,
+	); err != nil {
+		_, _ = m.ReplyText(c, fmt.Sprintf("An error occurred while seeking the track: %s", err.Error()), replyOpts)
+		return nil
+	}
+
+	_, err := m.ReplyText(c, fmt.Sprintf("<b>Stream moved to %s of %s by</b> %s", utils.SecToMin(toSeek), utils.SecToMin(playingSong.Duration), firstName(c, m)), replyOpts)
+	return err
+}
+
+// seekHandler handles the /seek command. A plain timestamp seeks to that position, while a
+// leading + or - seeks relative to the current position.
+func seekHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
 	args := Args(m)
 	if args == "" {
-		_, _ = m.ReplyText(c, "<b>Usage:</b> /seek duration\n<b>Example:</b> <code>/seek 15</code>", replyOpts)
+		_, _ = m.ReplyText(c, "<b>Usage:</b> /seek [+|-]timestamp\n<b>Examples:</b> <code>/seek 1:23</code>, <code>/seek +30</code>, <code>/seek -15</code>", replyOpts)
 		return nil
 	}
 
-	seekTime, err := strconv.Atoi(args)
+	sign := 0
+	switch {
+	case strings.HasPrefix(args, "+"):
+		sign, args = 1, args[1:]
+	case strings.HasPrefix(args, "-"):
+		sign, args = -1, args[1:]
+	}
+
+	seekTime, err := utils.ParseTimestamp(args)
 	if err != nil {
-		_, _ = m.ReplyText(c, "Invalid seek time provided. Please use a valid number of seconds.", nil)
+		_, _ = m.ReplyText(c, "Invalid seek time provided. Use seconds or a timestamp like 1:23.", nil)
+		return nil
+	}
+
+	playingSong := seekableTrack(c, m)
+	if playingSong == nil {
 		return nil
 	}
 
-	if seekTime < 10 {
-		_, _ = m.ReplyText(c, "Minimum seek time is 10 seconds.", nil)
+	toSeek := seekTime
+	if sign != 0 {
+		currDur, err := vc.Calls.PlayedTime(m.ChatId)
+		if err != nil {
+			_, _ = m.ReplyText(c, "Failed to fetch the duration of the ongoing stream.", nil)
+			return nil
+		}
+		toSeek = int(currDur) + sign*seekTime
+	}
+
+	return seekTo(c, m, playingSong, toSeek)
+}
+
+// rewindHandler handles the /rewind command.
+func rewindHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	seconds := defaultRewind
+	if args := Args(m); args != "" {
+		var err error
+		if seconds, err = utils.ParseTimestamp(args); err != nil {
+			_, _ = m.ReplyText(c, "Invalid rewind time provided. Use seconds or a timestamp like 1:23.", nil)
+			return nil
+		}
+	}
+
+	playingSong := seekableTrack(c, m)
+	if playingSong == nil {
 		return nil
 	}
 
-	currDur, err := vc.Calls.PlayedTime(chatID)
+	currDur, err := vc.Calls.PlayedTime(m.ChatId)
 	if err != nil {
 		_, _ = m.ReplyText(c, "Failed to fetch the duration of the ongoing stream.", nil)
 		return nil
 	}
 
-	toSeek := int(currDur) + seekTime
-	if toSeek >= playingSong.Duration {
-		_, _ = m.ReplyText(c, fmt.Sprintf("You cannot seek beyond the track duration. Maximum allowed is %s.", utils.SecToMin(playingSong.Duration)), nil)
-		return nil
+	return seekTo(c, m, playingSong, int(currDur)-seconds)
+}
+
+// replayHandler handles the /replay command.
+func replayHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
 	}
 
-	if err = vc.Calls.SeekStream(
-		c,
-		chatID,
-		playingSong.FilePath,
-		toSeek,
-		playingSong.Duration,
-		playingSong.IsVideo,
-	); err != nil {
-		_, _ = m.ReplyText(c, fmt.Sprintf("An error occurred while seeking the track: %s", err.Error()), replyOpts)
+	playingSong := seekableTrack(c, m)
+	if playingSong == nil {
 		return nil
 	}
-
-	_, _ = m.ReplyText(c, fmt.Sprintf("<b>Stream skipped %s and started from %s seconds by</b> %s", utils.SecToMin(seekTime), utils.SecToMin(toSeek), firstName(c, m)), replyOpts)
-	return nil
+	return seekTo(c, m, playingSong, 0)
 }
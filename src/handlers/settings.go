@@ -20,6 +20,49 @@ import (
 	td "github.com/AshokShau/gotdbot"
 )
 
+// Values the queue limit, duplicate and volume buttons cycle through. A limit of 0 means unlimited.
+var (
+	queueLimitOptions         = []int{10, 25, 50, 100}
+	userQueueLimitOptions     = []int{0, 3, 5, 10}
+	queueDurationLimitOptions = []int{0, 30 * 60, 60 * 60, 2 * 60 * 60, 4 * 60 * 60}
+	duplicateModeOptions      = []string{utils.DuplicateReject, utils.DuplicateWarn, utils.DuplicateAllow}
+	volumeOptions             = []int{50, 75, 100, 125, 150, 200}
+)
+
+// chatSettings collects the current settings of a chat for the settings keyboard.
+func chatSettings(chatID int64) core.ChatSettings {
+	playMode := utils.Everyone
+	if db.Instance.GetPlayMode(chatID) {
+		playMode = utils.Admins
+	}
+	language, _ := db.Instance.GetLanguage(chatID)
+
+	return core.ChatSettings{
+		PlayMode:           playMode,
+		AdminMode:          db.Instance.GetAdminMode(chatID),
+		CmdDelete:          db.Instance.GetCmdDelete(chatID),
+		Language:           language,
+		QueueLimit:         db.Instance.GetQueueLimit(chatID),
+		UserQueueLimit:     db.Instance.GetUserQueueLimit(chatID),
+		QueueDurationLimit: db.Instance.GetQueueDurationLimit(chatID),
+		FairQueue:          db.Instance.GetFairQueue(chatID),
+		DuplicateMode:      db.Instance.GetDuplicateMode(chatID),
+		Volume:             db.Instance.GetVolume(chatID),
+		Normalize:          db.Instance.GetNormalize(chatID),
+		Quality:            db.Instance.GetQuality(chatID),
+	}
+}
+
+// nextOption returns the option that follows current, wrapping around to the first one.
+func nextOption[T comparable](options []T, current T) T {
+	for i, v := range options {
+		if v == current {
+			return options[(i+1)%len(options)]
+		}
+	}
+	return options[0]
+}
+
 func settingsHandler(c *td.Client, m *td.Message) error {
 	if m.IsPrivate() {
 		return nil
@@ -48,16 +91,6 @@ func settingsHandler(c *td.Client, m *td.Message) error {
 		return nil
 	}
 
-	// Get current settings
-	getPlayMode := db.Instance.GetPlayMode(chatID)
-	playModeStr := utils.Everyone
-	if getPlayMode {
-		playModeStr = utils.Admins
-	}
-	getAdminMode := db.Instance.GetAdminMode(chatID)
-	cmdDelete := db.Instance.GetCmdDelete(chatID)
-	language, _ := db.Instance.GetLanguage(chatID)
-
 	chat, err := m.GetChat(c)
 	if err != nil {
 		c.Logger.Warn("Failed to get chat", "error", err)
@@ -67,7 +100,7 @@ func settingsHandler(c *td.Client, m *td.Message) error {
 	text := fmt.Sprintf("<u><b>%s settings</b></u>\n\nClick the buttons below to change this chat's current settings.",
 		chat.Title)
 
-	_, err = m.ReplyText(c, text, &td.SendTextMessageOpts{ReplyMarkup: core.SettingsKeyboard(playModeStr, getAdminMode, cmdDelete, language), ParseMode: td.ParseModeHTML})
+	_, err = m.ReplyText(c, text, &td.SendTextMessageOpts{ReplyMarkup: core.SettingsKeyboard(chatSettings(chatID)), ParseMode: td.ParseModeHTML})
 	return err
 }
 
@@ -127,19 +160,31 @@ func settingsCallbackHandler(c *td.Client, cb *td.UpdateNewCallbackQuery) error
 		_ = db.Instance.SetAdminMode(chatID, newMode)
 	case "lang":
 		return cb.Answer(c, 0, true, "Language selection is not yet implemented via this menu.", "")
+	case "qlimit":
+		_ = db.Instance.SetQueueLimit(chatID, nextOption(queueLimitOptions, db.Instance.GetQueueLimit(chatID)))
+	case "ulimit":
+		_ = db.Instance.SetUserQueueLimit(chatID, nextOption(userQueueLimitOptions, db.Instance.GetUserQueueLimit(chatID)))
+	case "dlimit":
+		_ = db.Instance.SetQueueDurationLimit(chatID, nextOption(queueDurationLimitOptions, db.Instance.GetQueueDurationLimit(chatID)))
+	case "fair":
+		fair := !db.Instance.GetFairQueue(chatID)
+		_ = db.Instance.SetFairQueue(chatID, fair)
+		cache.ChatCache.SetFairMode(chatID, fair)
+	case "dup":
+		_ = db.Instance.SetDuplicateMode(chatID, nextOption(duplicateModeOptions, db.Instance.GetDuplicateMode(chatID)))
+	case "volume":
+		_ = db.Instance.SetVolume(chatID, nextOption(volumeOptions, db.Instance.GetVolume(chatID)))
+		restartForSettings(c, chatID)
+	case "norm":
+		_ = db.Instance.SetNormalize(chatID, !db.Instance.GetNormalize(chatID))
+		restartForSettings(c, chatID)
+	case "quality":
+		_ = db.Instance.SetQuality(chatID, nextOption(utils.QualityNames, db.Instance.GetQuality(chatID)))
+		restartForSettings(c, chatID)
 	default:
 		return cb.Answer(c, 0, true, "Unknown setting", "")
 	}
 
-	getPlayMode := db.Instance.GetPlayMode(chatID)
-	playModeStr := utils.Everyone
-	if getPlayMode {
-		playModeStr = utils.Admins
-	}
-	getAdminMode := db.Instance.GetAdminMode(chatID)
-	cmdDelete := db.Instance.GetCmdDelete(chatID)
-	language, _ := db.Instance.GetLanguage(chatID)
-
 	chat, err := c.GetChat(chatID)
 	if err != nil {
 		c.Logger.Warn("Failed to get chat", "error", err)
@@ -149,7 +194,7 @@ func settingsCallbackHandler(c *td.Client, cb *td.UpdateNewCallbackQuery) error
 	text := fmt.Sprintf("<u><b>%s settings</b></u>\n\nClick the buttons below to change this chat's current settings.",
 		chat.Title)
 
-	_, err = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.SettingsKeyboard(playModeStr, getAdminMode, cmdDelete, language), ParseMode: td.ParseModeHTML})
+	_, err = cb.EditMessageText(c, text, &td.EditTextMessageOpts{ReplyMarkup: core.SettingsKeyboard(chatSettings(chatID)), ParseMode: td.ParseModeHTML})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,40 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"fmt"
+
+	"ashokshau/tgmusic/src/core/cache"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// shuffleHandler handles the /shuffle command.
+func shuffleHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+
+	if !cache.ChatCache.IsActive(chatID) {
+		_, _ = m.ReplyText(c, "The bot is not streaming in the video chat.", nil)
+		return nil
+	}
+
+	n := cache.ChatCache.ShuffleQueue(chatID)
+	if n == 0 {
+		_, _ = m.ReplyText(c, "There are not enough upcoming tracks to shuffle.", nil)
+		return nil
+	}
+
+	_, err := m.ReplyText(c, fmt.Sprintf("%d upcoming tracks have been shuffled by %s.", n, firstName(c, m)), replyOpts)
+	return err
+}
@@ -9,6 +9,9 @@
 package handlers
 
 import (
+	"fmt"
+	"strconv"
+
 	"ashokshau/tgmusic/src/core/cache"
 	"ashokshau/tgmusic/src/vc"
 
@@ -28,6 +31,40 @@ func skipHandler(c *td.Client, m *td.Message) error {
 		return nil
 	}
 
-	_ = vc.Calls.PlayNext(c, chatID)
+	_ = vc.Calls.Skip(c, chatID)
+	return nil
+}
+
+// skipToHandler handles the /skipto command.
+func skipToHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+
+	if !cache.ChatCache.IsActive(chatID) {
+		_, _ = m.ReplyText(c, "The bot is not streaming in the video chat.", nil)
+		return nil
+	}
+
+	index, err := strconv.Atoi(Args(m))
+	if err != nil {
+		_, _ = m.ReplyText(c, "<b>Usage:</b> <code>/skipto [track number]</code>", replyOpts)
+		return nil
+	}
+
+	queueLen := cache.ChatCache.GetQueueLength(chatID)
+	if queueLen <= 1 {
+		_, _ = m.ReplyText(c, "There are no upcoming tracks.", nil)
+		return nil
+	}
+	if !cache.ChatCache.SkipTo(chatID, index) {
+		_, _ = m.ReplyText(c, fmt.Sprintf("Track number must be between 1 and %d.", queueLen-1), nil)
+		return nil
+	}
+
+	_, _ = m.ReplyText(c, fmt.Sprintf("Skipping to track #%d. Requested by %s.", index, firstName(c, m)), replyOpts)
+	_ = vc.Calls.Skip(c, chatID)
 	return nil
 }
@@ -0,0 +1,192 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"ashokshau/tgmusic/config"
+	"ashokshau/tgmusic/src/core"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/utils"
+	"ashokshau/tgmusic/src/vc"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// maxClipSize is the largest file accepted as a soundboard clip.
+const maxClipSize = 20 * 1024 * 1024
+
+// clipNameRegex matches valid clip names, which must fit in callback data.
+var clipNameRegex = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// soundboardHandler handles the /soundboard command, which saves short audio clips and mixes
+// them over the playing track on demand.
+func soundboardHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+	args := strings.Fields(strings.ToLower(Args(m)))
+	if len(args) == 0 {
+		return showSoundboard(c, m)
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 2 {
+			_, err := m.ReplyText(c, "Reply to an audio file with <code>/soundboard add &lt;name&gt;</code>.", replyOpts)
+			return err
+		}
+		return addClip(c, m, args[1])
+
+	case "rm", "remove":
+		if len(args) < 2 {
+			_, err := m.ReplyText(c, "<b>Usage:</b> <code>/soundboard rm &lt;name&gt;</code>", replyOpts)
+			return err
+		}
+		removed, err := db.Instance.RemoveSoundClip(chatID, args[1])
+		if err != nil {
+			_, _ = m.ReplyText(c, "Failed to remove the clip.", nil)
+			return err
+		}
+		if !removed {
+			_, err = m.ReplyText(c, "There is no clip with that name.", nil)
+			return err
+		}
+		_, err = m.ReplyText(c, fmt.Sprintf("Removed the clip <b>%s</b>.", args[1]), replyOpts)
+		return err
+	}
+
+	clip := db.Instance.GetSoundClip(chatID, args[0])
+	if clip == nil {
+		_, err := m.ReplyText(c, "There is no clip with that name. Use /soundboard to list them.", nil)
+		return err
+	}
+	if err := playClip(c, chatID, clip); err != nil {
+		_, err = m.ReplyText(c, fmt.Sprintf("❌ %s", err.Error()), nil)
+		return err
+	}
+	return nil
+}
+
+// showSoundboard lists a chat's clips with a button for each.
+func showSoundboard(c *td.Client, m *td.Message) error {
+	clips := db.Instance.GetSoundClips(m.ChatId)
+	usage := "<b>Usage:</b>\n<code>/soundboard &lt;name&gt;</code> plays a clip over the current track.\n" +
+		"<code>/soundboard add &lt;name&gt;</code> in reply to an audio file saves a clip.\n" +
+		"<code>/soundboard rm &lt;name&gt;</code> removes a clip."
+	if len(clips) == 0 {
+		_, err := m.ReplyText(c, "<b>🔊 Soundboard</b>\n\nNo clips have been saved yet.\n\n"+usage, replyOpts)
+		return err
+	}
+
+	names := make([]string, len(clips))
+	for i, clip := range clips {
+		names[i] = clip.Name
+	}
+	_, err := m.ReplyText(c, fmt.Sprintf("<b>🔊 Soundboard</b> (%d/%d clips)\n\nTap a clip to play it over the current track.\n\n%s",
+		len(clips), config.SoundboardMaxClips, usage),
+		&td.SendTextMessageOpts{ParseMode: "HTML", ReplyMarkup: core.SoundboardKeyboard(names)})
+	return err
+}
+
+// addClip saves the audio the command replies to as a clip.
+func addClip(c *td.Client, m *td.Message, name string) error {
+	chatID := m.ChatId
+	if !clipNameRegex.MatchString(name) || name == "add" || name == "rm" || name == "remove" {
+		_, err := m.ReplyText(c, "Clip names may only use up to 32 lowercase letters, digits, <code>-</code> and <code>_</code>, and cannot be add, rm or remove.", replyOpts)
+		return err
+	}
+
+	if m.ReplyToMessageID() == 0 {
+		_, err := m.ReplyText(c, "Reply to an audio file or voice message to save it as a clip.", nil)
+		return err
+	}
+	reply, err := m.GetRepliedMessage(c)
+	if err != nil || !isAudioMedia(reply) {
+		_, err = m.ReplyText(c, "Reply to an audio file or voice message to save it as a clip.", nil)
+		return err
+	}
+
+	file, _ := getFile(reply)
+	if file == nil || file.Size > maxClipSize {
+		_, err = m.ReplyText(c, fmt.Sprintf("Clips must be smaller than %d MB.", maxClipSize/(1024*1024)), nil)
+		return err
+	}
+	duration := utils.GetFileDur(reply)
+	if duration > int(config.SoundboardMaxLength) {
+		_, err = m.ReplyText(c, fmt.Sprintf("Clips can be at most %d seconds long.", config.SoundboardMaxLength), nil)
+		return err
+	}
+
+	if db.Instance.GetSoundClip(chatID, name) == nil && len(db.Instance.GetSoundClips(chatID)) >= int(config.SoundboardMaxClips) {
+		_, err = m.ReplyText(c, fmt.Sprintf("The soundboard is full (max %d clips). Remove one with <code>/soundboard rm &lt;name&gt;</code>.", config.SoundboardMaxClips), replyOpts)
+		return err
+	}
+
+	clip := db.SoundClip{Name: name, FileID: reply.RemoteFileID(), Duration: duration, AddedBy: m.SenderID()}
+	if err = db.Instance.SetSoundClip(chatID, clip); err != nil {
+		_, _ = m.ReplyText(c, "Failed to save the clip.", nil)
+		return err
+	}
+	_, err = m.ReplyText(c, fmt.Sprintf("🔊 Saved the clip <b>%s</b>. Play it with <code>/soundboard %s</code> or from /soundboard.", name, name), replyOpts)
+	return err
+}
+
+// isAudioMedia reports whether a message holds audio that can be used as a clip.
+func isAudioMedia(m *td.Message) bool {
+	if m == nil || m.Content == nil {
+		return false
+	}
+	switch content := m.Content.(type) {
+	case *td.MessageAudio, *td.MessageVoiceNote:
+		return true
+	case *td.MessageDocument:
+		return content.Document != nil && strings.HasPrefix(strings.ToLower(content.Document.MimeType), "audio/")
+	}
+	return false
+}
+
+// playClip downloads a clip if needed and mixes it over the chat's stream.
+func playClip(c *td.Client, chatID int64, clip *db.SoundClip) error {
+	file, err := c.GetRemoteFile(clip.FileID, nil)
+	if err != nil {
+		return errors.New("the clip is no longer available")
+	}
+	download, err := file.Download(c, 0, 0, 1, &td.DownloadFileOpts{Synchronous: true})
+	if err != nil {
+		return errors.New("failed to download the clip")
+	}
+	return vc.Calls.PlayClip(chatID, download.Local.Path)
+}
+
+// soundboardCallbackHandler plays the clip of a soundboard button.
+func soundboardCallbackHandler(c *td.Client, cb *td.UpdateNewCallbackQuery) error {
+	if !adminModeCB(c, cb) {
+		return td.EndGroups
+	}
+
+	name := strings.TrimPrefix(cb.DataString(), "sb_")
+	clip := db.Instance.GetSoundClip(cb.ChatId, name)
+	if clip == nil {
+		_ = cb.Answer(c, 0, false, "This clip was removed.", "")
+		return nil
+	}
+	if err := playClip(c, cb.ChatId, clip); err != nil {
+		_ = cb.Answer(c, 0, false, err.Error(), "")
+		return nil
+	}
+	_ = cb.Answer(c, 0, false, "🔊 "+name, "")
+	return nil
+}
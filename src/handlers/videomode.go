@@ -0,0 +1,57 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/utils"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// videoModeHandler handles the /vmode command, which switches video playback between the
+// assistant's camera and a screen share.
+func videoModeHandler(c *td.Client, m *td.Message) error {
+	if !adminMode(c, m) {
+		return td.EndGroups
+	}
+
+	chatID := m.ChatId
+	mode := strings.ToLower(Args(m))
+	if mode == "" {
+		text := fmt.Sprintf("<b>Video mode:</b> %s\n\n<b>Usage:</b> <code>/vmode [camera|screen]</code>", db.Instance.GetVideoMode(chatID))
+		_, err := m.ReplyText(c, text, replyOpts)
+		return err
+	}
+
+	if mode != utils.VideoModeCamera && mode != utils.VideoModeScreen {
+		_, err := m.ReplyText(c, "Unknown video mode. Use camera or screen.", nil)
+		return err
+	}
+
+	if err := db.Instance.SetVideoMode(chatID, mode); err != nil {
+		_, _ = m.ReplyText(c, "Failed to save the video mode.", nil)
+		return err
+	}
+
+	label := "camera"
+	if mode == utils.VideoModeScreen {
+		label = "screen share"
+	}
+	text := fmt.Sprintf("Video will be shown as the %s.\nChanged by: %s", label, firstName(c, m))
+	if track := cache.ChatCache.GetPlayingTrack(chatID); track != nil && track.IsVideo {
+		text += restartForSettings(c, chatID)
+	}
+	_, err := m.ReplyText(c, text, nil)
+	return err
+}
@@ -0,0 +1,132 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"sync"
+	"time"
+
+	"ashokshau/tgmusic/config"
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/vc"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// skipVote holds the users that voted to skip a chat's current track. started identifies the
+// play of the track the votes are for, so a track played again starts a new vote.
+type skipVote struct {
+	started time.Time
+	voters  map[int64]struct{}
+}
+
+var (
+	skipVotesMu sync.Mutex
+	skipVotes   = make(map[int64]*skipVote)
+)
+
+// votesNeeded returns how many votes skip a track with the given number of listeners.
+func votesNeeded(listeners int) int {
+	needed := (listeners*int(config.VoteSkipPercent) + 99) / 100
+	return max(needed, 1)
+}
+
+// castSkipVote records a vote against the play of a track that began at started and returns the
+// vote count, whether the vote was new and whether it passed the vote. Votes cast for an earlier
+// play are discarded. A passed vote is reset in the same step, so only one caller sees it pass.
+func castSkipVote(chatID, userID int64, started time.Time, needed int) (votes int, counted, passed bool) {
+	skipVotesMu.Lock()
+	defer skipVotesMu.Unlock()
+
+	vote := skipVotes[chatID]
+	if vote == nil || !vote.started.Equal(started) {
+		vote = &skipVote{started: started, voters: make(map[int64]struct{})}
+		skipVotes[chatID] = vote
+	}
+	if _, ok := vote.voters[userID]; ok {
+		return len(vote.voters), false, false
+	}
+	vote.voters[userID] = struct{}{}
+	votes = len(vote.voters)
+	if votes < needed {
+		return votes, true, false
+	}
+	delete(skipVotes, chatID)
+	return votes, true, true
+}
+
+// watchSkipVotes discards a chat's skip votes once its playback stops.
+func watchSkipVotes() {
+	cache.ChatCache.OnChange(func(chatID int64) {
+		if cache.ChatCache.IsActive(chatID) {
+			return
+		}
+		skipVotesMu.Lock()
+		delete(skipVotes, chatID)
+		skipVotesMu.Unlock()
+	})
+}
+
+// voteSkip casts userID's vote and skips the playing track once enough listeners agree.
+// It returns a message describing the outcome.
+func voteSkip(c *td.Client, chatID, userID int64, name string) string {
+	track := cache.ChatCache.GetPlayingTrack(chatID)
+	started, playing := vc.Calls.PlayStarted(chatID)
+	if !cache.ChatCache.IsActive(chatID) || track == nil || !playing {
+		return "The bot is not streaming in the video chat."
+	}
+
+	if !vc.Calls.IsListener(chatID, userID) {
+		return "Only listeners in the video chat can vote to skip."
+	}
+
+	needed := votesNeeded(vc.Calls.ListenerCount(chatID))
+	votes, counted, passed := castSkipVote(chatID, userID, started, needed)
+	if !counted {
+		return fmt.Sprintf("You have already voted to skip this track (%d/%d).", votes, needed)
+	}
+
+	if !passed {
+		return fmt.Sprintf("%s voted to skip %s (%d/%d).", name, track.Name, votes, needed)
+	}
+
+	if err := vc.Calls.Skip(c, chatID); err != nil {
+		c.Logger.Warn("Failed to skip after vote", "chatID", chatID, "error", err)
+		return "Vote passed, but the track could not be skipped."
+	}
+	return fmt.Sprintf("Vote passed (%d/%d). Skipped %s.", votes, needed, track.Name)
+}
+
+// voteSkipHandler handles the /voteskip command.
+func voteSkipHandler(c *td.Client, m *td.Message) error {
+	if m.IsPrivate() {
+		return td.EndGroups
+	}
+
+	text := voteSkip(c, m.ChatId, m.SenderID(), firstName(c, m))
+	_, _ = m.ReplyText(c, html.EscapeString(text), replyOpts)
+	return nil
+}
+
+// voteSkipCallbackHandler handles the vote button on the now-playing message.
+func voteSkipCallbackHandler(c *td.Client, cb *td.UpdateNewCallbackQuery) error {
+	if cb.IsPrivate() {
+		return td.EndGroups
+	}
+
+	name := "Unknown"
+	if user, err := c.GetUser(cb.SenderUserId); err == nil {
+		name = user.FirstName
+	}
+
+	_ = cb.Answer(c, 0, false, voteSkip(c, cb.ChatId, cb.SenderUserId, name), "")
+	return nil
+}
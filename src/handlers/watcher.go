@@ -11,6 +11,8 @@ package handlers
 import (
 	"ashokshau/tgmusic/src/core"
 	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/vc"
 	"fmt"
 	"time"
 
@@ -48,7 +50,21 @@ func handleVoiceChatMessage(c *td.Client, update *td.UpdateNewMessage) error {
 	case *td.MessageVideoChatStarted:
 		cache.ChatCache.ClearChat(chatID)
 		message = "🎙️ Video chat started!\nUse /play <song name> to play music."
+		if on, _ := db.Instance.GetAlwaysOn(chatID); on {
+			message = "🎙️ Video chat started!\n📻 24/7 mode is on, starting playback."
+			go func() {
+				if err := vc.Calls.StartAlwaysOn(c, chatID); err != nil {
+					c.Logger.Warn("failed to start 24/7 playback", "chat_id", chatID, "error", err)
+				}
+			}()
+		}
 	case *td.MessageVideoChatEnded:
+		if on, _ := db.Instance.GetAlwaysOn(chatID); on {
+			// Leave the ended call now, so its stream ending does not refill the queue.
+			_ = vc.Calls.Stop(chatID, false)
+			message = "🎧 Video chat ended!\n📻 24/7 mode will resume when a new video chat starts."
+			break
+		}
 		cache.ChatCache.ClearChat(chatID)
 		message = "🎧 Video chat ended!\nAll queues cleared."
 	default:
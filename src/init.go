@@ -10,6 +10,7 @@ package src
 
 import (
 	"ashokshau/tgmusic/config"
+	"ashokshau/tgmusic/src/core/cache"
 	"ashokshau/tgmusic/src/core/db"
 	"ashokshau/tgmusic/src/vc"
 
@@ -21,6 +22,16 @@ func Init(client *gotdbot.Client) error {
 		return err
 	}
 
+	if err := cache.InitQueueStore(config.QueueStore, config.RedisUrl); err != nil {
+		return err
+	}
+	cache.LoadDefaults = func(chatID int64) cache.ChatDefaults {
+		return cache.ChatDefaults{
+			Fair:   db.Instance.GetFairQueue(chatID),
+			Repeat: db.Instance.GetRepeatMode(chatID),
+		}
+	}
+
 	for _, session := range config.SessionStrings {
 		_, err := vc.Calls.StartClient(config.ApiId, config.ApiHash, session)
 		if err != nil {
@@ -29,5 +40,7 @@ func Init(client *gotdbot.Client) error {
 	}
 
 	vc.Calls.RegisterHandlers(client)
+	vc.Calls.RestoreQueues(client)
+	vc.Calls.RestoreAlwaysOn(client)
 	return nil
 }
@@ -126,3 +126,38 @@ func SecToMin(seconds int) string {
 
 	return fmt.Sprintf("%d:%02d", m, s)
 }
+
+// TrackDuration formats the duration of a queued track for display, marking live streams.
+func TrackDuration(t *CachedTrack) string {
+	if t.IsLive {
+		return "🔴 Live"
+	}
+	return SecToMin(t.Duration) + " min"
+}
+
+// ProgressBar draws how far elapsed is into total as a text bar of width cells.
+func ProgressBar(elapsed, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = max(0, min(width, elapsed*width/total))
+	}
+	return strings.Repeat("▰", filled) + strings.Repeat("▱", width-filled)
+}
+
+// ParseTimestamp converts a timestamp of the form SS, MM:SS or HH:MM:SS to seconds.
+func ParseTimestamp(s string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+
+	total := 0
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || (i > 0 && n >= 60) {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		total = total*60 + n
+	}
+	return total, nil
+}
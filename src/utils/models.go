@@ -15,6 +15,7 @@ type CachedTrack struct {
 	Name      string `json:"name"`
 	Loop      int    `json:"loop"`
 	User      string `json:"user"`
+	UserID    int64  `json:"user_id,omitempty"`
 	FilePath  string `json:"file_path"`
 	Thumbnail string `json:"thumbnail"`
 	TrackID   string `json:"track_id"`
@@ -23,6 +24,11 @@ type CachedTrack struct {
 	Views     string `json:"views"`
 	IsVideo   bool   `json:"is_video"`
 	Platform  string `json:"platform"`
+	// IsLive marks an endless stream such as an internet radio station, which has no duration
+	// and cannot be seeked.
+	IsLive bool `json:"is_live,omitempty"`
+	// Quality is the quality profile the track is downloaded with.
+	Quality string `json:"quality,omitempty"`
 }
 
 // TrackInfo holds detailed information about a specific track, including its CDN URL, cover art, and lyrics.
@@ -32,6 +38,8 @@ type TrackInfo struct {
 	CdnURL   string `json:"cdnurl"`
 	Key      string `json:"key"`
 	Platform string `json:"platform"`
+	// Quality is the quality profile to download the track with.
+	Quality string `json:"-"`
 }
 
 // MusicTrack represents a single music track returned from a search query.
@@ -45,6 +53,7 @@ type MusicTrack struct {
 	Channel   string `json:"channel"`
 	Views     string `json:"views"`
 	Platform  string `json:"platform"`
+	IsLive    bool   `json:"is_live,omitempty"`
 }
 
 // PlatformTracks is a collection of music tracks, typically returned from a search operation.
@@ -68,6 +77,8 @@ const (
 	TwitchClip = "twitch_clip"
 	Kick       = "kick"
 	KickClip   = "kick_clip"
+	// Ingest marks a live stream pushed to the bot by a streamer with /ingest.
+	Ingest = "ingest"
 )
 
 const (
@@ -75,6 +86,21 @@ const (
 	Everyone = "everyone"
 )
 
+// Duplicate modes decide what happens when a song that is already queued is added again.
+const (
+	DuplicateReject = "reject"
+	DuplicateWarn   = "warn"
+	DuplicateAllow  = "allow"
+)
+
+// Video modes decide how video tracks are shown in the voice chat.
+const (
+	// VideoModeCamera sends video as the assistant's camera.
+	VideoModeCamera = "camera"
+	// VideoModeScreen sends video as a screen share, leaving the main connection audio-only.
+	VideoModeScreen = "screen"
+)
+
 // FFProbeFormat defines the structure for parsing the format information from ffprobe's JSON output.
 type FFProbeFormat struct {
 	Format struct {
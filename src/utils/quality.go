@@ -0,0 +1,72 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package utils
+
+// Names of the stream quality profiles.
+const (
+	QualityLow      = "low"
+	QualityStandard = "standard"
+	QualityHigh     = "high"
+)
+
+// QualityNames lists the quality profiles from lowest to highest.
+var QualityNames = []string{QualityLow, QualityStandard, QualityHigh}
+
+// QualityProfile holds the download format and the stream parameters sent to ntgcalls for
+// one quality level.
+type QualityProfile struct {
+	SampleRate   int
+	ChannelCount int
+	// MaxWidth and MaxHeight bound the video resolution; smaller videos keep their size.
+	MaxWidth  int
+	MaxHeight int
+	Fps       int
+	// AudioFormat and VideoFormat are the yt-dlp format selectors for audio and video downloads.
+	AudioFormat string
+	VideoFormat string
+}
+
+// QualityProfiles are the available quality profiles by name.
+var QualityProfiles = map[string]QualityProfile{
+	QualityLow: {
+		SampleRate:   24000,
+		ChannelCount: 1,
+		MaxWidth:     640,
+		MaxHeight:    360,
+		Fps:          24,
+		AudioFormat:  "bestaudio[abr<=64]/worstaudio/bestaudio",
+		VideoFormat:  "bestvideo[height<=360]+bestaudio[abr<=64]/best[height<=360]/best",
+	},
+	QualityStandard: {
+		SampleRate:   48000,
+		ChannelCount: 2,
+		MaxWidth:     1280,
+		MaxHeight:    720,
+		Fps:          30,
+		AudioFormat:  "bestaudio[ext=m4a]/bestaudio",
+		VideoFormat:  "bestvideo[height<=720]+bestaudio/best[height<=720]",
+	},
+	QualityHigh: {
+		SampleRate:   48000,
+		ChannelCount: 2,
+		MaxWidth:     1920,
+		MaxHeight:    1080,
+		Fps:          30,
+		AudioFormat:  "bestaudio",
+		VideoFormat:  "bestvideo[height<=1080]+bestaudio/best[height<=1080]",
+	},
+}
+
+// GetQualityProfile returns the named quality profile, or the standard one for an unknown name.
+func GetQualityProfile(name string) QualityProfile {
+	if profile, ok := QualityProfiles[name]; ok {
+		return profile
+	}
+	return QualityProfiles[QualityStandard]
+}
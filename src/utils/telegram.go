@@ -74,3 +74,29 @@ func resolveMessage(client *td.Client, link string) (*td.Message, error) {
 	}
 	return nil, fmt.Errorf("failed to get message link info")
 }
+
+// GetFloodWait returns how many seconds Telegram asked to wait before retrying a request
+// that failed with a flood wait, or 0 for any other error.
+func GetFloodWait(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	type retryError interface {
+		GetRetryAfter() int
+	}
+
+	if re, ok := err.(retryError); ok {
+		return re.GetRetryAfter()
+	}
+
+	if tdErr, ok := err.(*td.Error); ok {
+		return tdErr.GetRetryAfter()
+	}
+
+	if tdErr, ok := err.(td.Error); ok {
+		return tdErr.GetRetryAfter()
+	}
+
+	return 0
+}
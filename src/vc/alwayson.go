@@ -0,0 +1,180 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/core/dl"
+	"ashokshau/tgmusic/src/utils"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+const (
+	// minRefillPlayTime is how long the tracks of a refill must play for the refill to count
+	// as working. A queue that empties faster means its tracks failed to play.
+	minRefillPlayTime = 20 * time.Second
+	// maxRefillFailures is how many refills in a row may fail before 24/7 mode backs off.
+	maxRefillFailures = 3
+	// minRefillBackoff and maxRefillBackoff bound how long 24/7 mode waits before retrying
+	// after its refills keep failing. The wait doubles with every further failure.
+	minRefillBackoff = time.Minute
+	maxRefillBackoff = 30 * time.Minute
+)
+
+// alwaysOnUser is shown as the requester of the tracks 24/7 mode queues.
+const alwaysOnUser = "24/7"
+
+// alwaysOnState tracks the refills of a 24/7 chat, so failing refills back off instead of looping.
+type alwaysOnState struct {
+	lastRefill time.Time
+	failures   int
+	// retrying is set while a retry after a back-off runs, so it does not count as a failure
+	// of the refill before it.
+	retrying bool
+	retry    *time.Timer
+}
+
+// StartAlwaysOn starts playback in a 24/7 chat that is not playing anything, such as when a
+// new video chat starts or after a restart.
+func (c *TelegramCalls) StartAlwaysOn(bot *td.Client, chatID int64) error {
+	if cache.ChatCache.IsActive(chatID) {
+		return nil
+	}
+	c.alwaysOnMu.Lock()
+	if st := c.refills[chatID]; st != nil && st.retry != nil {
+		st.retry.Stop()
+	}
+	delete(c.refills, chatID)
+	c.alwaysOnMu.Unlock()
+
+	ok, err := c.refillAlwaysOn(bot, chatID)
+	if err == nil && !ok {
+		return errors.New("24/7 mode has nothing to play")
+	}
+	return err
+}
+
+// RestoreAlwaysOn resumes playback in the 24/7 chats that are idle after a restart. Chats
+// without a video chat resume when one starts.
+func (c *TelegramCalls) RestoreAlwaysOn(bot *td.Client) {
+	chats, err := db.Instance.GetAlwaysOnChats()
+	if err != nil {
+		logger.Warn("Failed to load the 24/7 chats", "error", err)
+		return
+	}
+	for _, chatID := range chats {
+		go func() {
+			if err := c.StartAlwaysOn(bot, chatID); err != nil {
+				logger.Info("[24/7] Could not resume playback", "chatID", chatID, "error", err)
+			}
+		}()
+	}
+}
+
+// retryAlwaysOn refills a 24/7 chat again once its back-off ends, unless something else was
+// played in the meantime.
+func (c *TelegramCalls) retryAlwaysOn(bot *td.Client, chatID int64) {
+	if cache.ChatCache.IsActive(chatID) {
+		return
+	}
+	c.alwaysOnMu.Lock()
+	if st := c.refills[chatID]; st != nil {
+		st.retrying = true
+		st.retry = nil
+	}
+	c.alwaysOnMu.Unlock()
+
+	if ok, err := c.refillAlwaysOn(bot, chatID); err != nil {
+		logger.Info("[24/7] Retrying playback failed", "chatID", chatID, "error", err)
+	} else if !ok {
+		logger.Info("[24/7] Nothing to retry", "chatID", chatID)
+	}
+}
+
+// refillAlwaysOn refills the emptied queue of a 24/7 chat from its seed and plays it. It
+// reports false if the chat is not in 24/7 mode or has nothing to refill from.
+func (c *TelegramCalls) refillAlwaysOn(bot *td.Client, chatID int64) (bool, error) {
+	enabled, seed := db.Instance.GetAlwaysOn(chatID)
+	if !enabled {
+		return false, nil
+	}
+
+	c.alwaysOnMu.Lock()
+	st := c.refills[chatID]
+	if st == nil {
+		st = &alwaysOnState{}
+		c.refills[chatID] = st
+	}
+	switch {
+	case st.retrying:
+		st.retrying = false
+	case !st.lastRefill.IsZero() && time.Since(st.lastRefill) < minRefillPlayTime:
+		st.failures++
+	default:
+		st.failures = 0
+	}
+	st.lastRefill = time.Now()
+	failures := st.failures
+	var backoff time.Duration
+	if failures >= maxRefillFailures {
+		backoff = min(minRefillBackoff<<min(failures-maxRefillFailures, 5), maxRefillBackoff)
+		if st.retry != nil {
+			st.retry.Stop()
+		}
+		st.retry = time.AfterFunc(backoff, func() { c.retryAlwaysOn(bot, chatID) })
+	}
+	c.alwaysOnMu.Unlock()
+
+	if backoff > 0 {
+		logger.Warn("[24/7] Refills keep failing, backing off", "chatID", chatID, "failures", failures, "retryIn", backoff)
+		_ = c.Stop(chatID, false)
+		_, _ = bot.SendTextMessage(chatID, fmt.Sprintf("📻 24/7 mode could not play anything. Retrying in %s.", backoff), nil)
+		return true, nil
+	}
+
+	if strings.HasPrefix(seed, "tgpl_") {
+		playlist, err := db.Instance.GetPlaylist(seed)
+		if err != nil || len(playlist.Songs) == 0 {
+			logger.Warn("[24/7] The seed playlist is missing or empty", "chatID", chatID, "playlist", seed, "error", err)
+			return false, nil
+		}
+
+		var tracks []*utils.CachedTrack
+		for _, track := range db.ConvertSongsToTracks(playlist.Songs) {
+			tracks = append(tracks, &utils.CachedTrack{
+				Name: track.Title, TrackID: track.Id, Duration: track.Duration, User: alwaysOnUser,
+				Platform: track.Platform, URL: dl.CanonicalURL(track.Url),
+			})
+		}
+		rand.Shuffle(len(tracks), func(i, j int) { tracks[i], tracks[j] = tracks[j], tracks[i] })
+		if limit := db.Instance.GetQueueLimit(chatID); len(tracks) > limit {
+			tracks = tracks[:limit]
+		}
+		cache.ChatCache.AddSongs(chatID, tracks)
+		return true, c.playCurrent(bot, chatID)
+	}
+
+	// Autoplay from the last YouTube track, or from the seed track after the history was
+	// cleared with the previous video chat.
+	lastSong := cache.ChatCache.GetLastYouTubeTrack(chatID)
+	if lastSong == nil {
+		if seed == "" {
+			return false, nil
+		}
+		lastSong = &utils.CachedTrack{TrackID: seed, Platform: utils.YouTube, User: alwaysOnUser}
+	}
+	return true, c.handleAutoplay(bot, chatID, lastSong)
+}
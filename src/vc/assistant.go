@@ -31,6 +31,8 @@ type Assistant struct {
 	inputGroupCalls    map[int64]tg.InputGroupCall
 	pendingConnections map[int64]*pendingConnection
 	waitConnect        map[int64]chan error
+	participants       map[int64]map[int64]struct{}
+	participantsCount  map[int64]int
 
 	streamEndCallbacks []ntgcalls.StreamEndCallback
 }
@@ -42,6 +44,8 @@ func newAssistant(app *tg.Client) (*Assistant, error) {
 		inputGroupCalls:    make(map[int64]tg.InputGroupCall),
 		pendingConnections: make(map[int64]*pendingConnection),
 		waitConnect:        make(map[int64]chan error),
+		participants:       make(map[int64]map[int64]struct{}),
+		participantsCount:  make(map[int64]int),
 	}
 	if app.IsConnected() {
 		self, err := app.GetMe()
@@ -58,13 +62,22 @@ func (a *Assistant) OnStreamEnd(callback ntgcalls.StreamEndCallback) {
 	a.streamEndCallbacks = append(a.streamEndCallbacks, callback)
 }
 
+// OnFrame registers a callback for the frames ntgcalls captures from the call.
+func (a *Assistant) OnFrame(callback ntgcalls.FrameCallback) {
+	a.binding.OnFrame(callback)
+}
+
 func (a *Assistant) Close() {
 	a.binding.Free()
 }
 
 func (a *Assistant) Play(ctx context.Context, chatId int64, mediaDescription ntgcalls.MediaDescription) error {
 	if a.binding.Calls()[chatId] != nil {
-		return a.binding.SetStreamSources(chatId, ntgcalls.CaptureStream, mediaDescription)
+		if err := a.binding.SetStreamSources(chatId, ntgcalls.CaptureStream, mediaDescription); err != nil {
+			return err
+		}
+		// Join or leave the screen share when the new media changes whether it uses one.
+		return a.joinPresentation(ctx, chatId, mediaDescription.Screen != nil)
 	}
 	if err := a.connectCall(ctx, chatId, mediaDescription, ""); err != nil {
 		return err
@@ -79,6 +92,8 @@ func (a *Assistant) stopCall(chatId int64, banned bool) error {
 	a.mu.Lock()
 	a.presentations = stdRemove(a.presentations, chatId)
 	delete(a.pendingConnections, chatId)
+	delete(a.participants, chatId)
+	delete(a.participantsCount, chatId)
 	inputGroupCall := a.inputGroupCalls[chatId]
 	a.mu.Unlock()
 
@@ -432,11 +447,15 @@ func (a *Assistant) handleUpdates() {
 	a.binding.OnConnectionChange(a.onConnectionChange)
 	a.binding.OnUpgrade(a.onUpgrade)
 
-	a.binding.OnStreamEnd(func(chatId int64, streamType ntgcalls.StreamType, streamDevice ntgcalls.StreamDevice) {
-		for _, callback := range a.streamEndCallbacks {
-			go callback(chatId, streamType, streamDevice)
-		}
-	})
+	a.binding.OnStreamEnd(a.emitStreamEnd)
+}
+
+// emitStreamEnd runs the stream end callbacks. ntgcalls reports the end of sources it reads
+// itself; external sources report it when their decoder finishes.
+func (a *Assistant) emitStreamEnd(chatId int64, streamType ntgcalls.StreamType, streamDevice ntgcalls.StreamDevice) {
+	for _, callback := range a.streamEndCallbacks {
+		go callback(chatId, streamType, streamDevice)
+	}
 }
 
 func (a *Assistant) onGroupCallParticipants(m tg.Update, _ *tg.Client) error {
@@ -453,6 +472,8 @@ func (a *Assistant) onGroupCallParticipants(m tg.Update, _ *tg.Client) error {
 		return nil
 	}
 
+	a.trackParticipants(chatId, participantsUpdate.Participants)
+
 	for _, participant := range participantsUpdate.Participants {
 		participantId := getParticipantId(participant.Peer)
 		if participantId != a.self.ID {
@@ -512,6 +533,73 @@ func (a *Assistant) onGroupCallParticipants(m tg.Update, _ *tg.Client) error {
 	return nil
 }
 
+// trackParticipants keeps the set of users other than the assistant that are in a chat's voice chat.
+func (a *Assistant) trackParticipants(chatId int64, participants []*tg.GroupCallParticipant) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	listeners := a.participants[chatId]
+	if listeners == nil {
+		listeners = make(map[int64]struct{})
+		a.participants[chatId] = listeners
+	}
+	for _, participant := range participants {
+		participantId := getParticipantId(participant.Peer)
+		if participantId == 0 || participantId == a.self.ID {
+			continue
+		}
+		if participant.Left {
+			delete(listeners, participantId)
+		} else {
+			listeners[participantId] = struct{}{}
+		}
+	}
+}
+
+// listenerCount returns how many users other than the assistant are in a chat's voice chat.
+// Participants that joined before the assistant never show up in updates, so the
+// count reported by Telegram is used when it is larger.
+func (a *Assistant) listenerCount(chatId int64) int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return max(len(a.participants[chatId]), a.participantsCount[chatId]-1)
+}
+
+// isListener reports whether a user is in a chat's voice chat. Users that joined before the
+// assistant are only known once the participant list is loaded, so it is fetched for users
+// not seen in updates.
+func (a *Assistant) isListener(chatId, userId int64) bool {
+	a.mu.RLock()
+	_, ok := a.participants[chatId][userId]
+	inputGroupCall := a.inputGroupCalls[chatId]
+	a.mu.RUnlock()
+	if ok {
+		return true
+	}
+	if inputGroupCall == nil {
+		return false
+	}
+
+	offset := ""
+	for {
+		res, err := a.App.PhoneGetGroupParticipants(inputGroupCall, []tg.InputPeer{}, []int32{}, offset, 500)
+		if err != nil {
+			a.App.Log.Warnf("failed to get voice chat participants: %v", err)
+			return false
+		}
+		a.trackParticipants(chatId, res.Participants)
+		for _, participant := range res.Participants {
+			if getParticipantId(participant.Peer) == userId && !participant.Left {
+				return true
+			}
+		}
+		if res.NextOffset == "" || len(res.Participants) == 0 {
+			return false
+		}
+		offset = res.NextOffset
+	}
+}
+
 func (a *Assistant) onGroupCall(m tg.Update, _ *tg.Client) error {
 	updateGroupCall := m.(*tg.UpdateGroupCall)
 	if groupCallRaw := updateGroupCall.Call; groupCallRaw != nil {
@@ -556,11 +644,14 @@ func (a *Assistant) onGroupCall(m tg.Update, _ *tg.Client) error {
 				ID:         groupCall.ID,
 				AccessHash: groupCall.AccessHash,
 			}
+			a.participantsCount[chatID] = int(groupCall.ParticipantsCount)
 			a.mu.Unlock()
 			return nil
 		case *tg.GroupCallDiscarded:
 			a.mu.Lock()
 			delete(a.inputGroupCalls, chatID)
+			delete(a.participants, chatID)
+			delete(a.participantsCount, chatID)
 			a.mu.Unlock()
 			_ = a.binding.Stop(chatID)
 			return nil
@@ -0,0 +1,341 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/vc/ntgcalls"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// bridge relays the voice chat audio of one chat into another's call, and optionally back.
+// Each chat is served by its own assistant.
+type bridge struct {
+	bot    *td.Client
+	chats  [2]int64
+	relays []*relay
+	ctx    context.Context
+	cancel context.CancelFunc
+	reason string
+}
+
+// relay carries one direction of a bridge.
+type relay struct {
+	from, to         int64
+	fromCall, toCall *Assistant
+	// audio buffers the source's participants by SSRC and its own output as ownSource.
+	audio *pcmMixer
+	// direct is set while the relay feeds the target's call itself because nothing plays there.
+	direct bool
+}
+
+// StartBridge relays the voice chat of chat from into chat to, and back as well when both is
+// set. The assistants join either voice chat if they are not in it yet.
+func (c *TelegramCalls) StartBridge(bot *td.Client, from, to int64, both bool) error {
+	if from == to {
+		return errors.New("a chat cannot be bridged to itself")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &bridge{bot: bot, chats: [2]int64{from, to}, ctx: ctx, cancel: cancel}
+	c.bridgeMu.Lock()
+	if c.bridges[from] != nil || c.bridges[to] != nil {
+		c.bridgeMu.Unlock()
+		cancel()
+		return errors.New("one of the chats is already bridged")
+	}
+	// Reserve both chats while joining, so they cannot be bridged twice.
+	c.bridges[from] = b
+	c.bridges[to] = b
+	c.bridgeMu.Unlock()
+
+	fail := func(err error) error {
+		c.bridgeMu.Lock()
+		delete(c.bridges, from)
+		delete(c.bridges, to)
+		c.bridgeMu.Unlock()
+		cancel()
+		c.leaveIdle(b)
+		return err
+	}
+
+	if err := c.separateAssistants(from, to); err != nil {
+		return fail(err)
+	}
+
+	calls := make(map[int64]*Assistant, 2)
+	for _, chatID := range b.chats {
+		call, err := c.ensureInCall(bot, chatID)
+		if err != nil {
+			return fail(fmt.Errorf("failed to join the voice chat of %d: %w", chatID, err))
+		}
+		calls[chatID] = call
+	}
+
+	b.relays = append(b.relays, &relay{from: from, to: to, fromCall: calls[from], toCall: calls[to], audio: newPCMMixer()})
+	if both {
+		b.relays = append(b.relays, &relay{from: to, to: from, fromCall: calls[to], toCall: calls[from], audio: newPCMMixer()})
+	}
+
+	for i, r := range b.relays {
+		if err := c.acquireCapture(r.fromCall, r.from); err != nil {
+			for _, done := range b.relays[:i] {
+				c.releaseCapture(done.fromCall, done.from)
+			}
+			return fail(fmt.Errorf("failed to capture the voice chat of %d: %w", r.from, err))
+		}
+	}
+
+	go c.runBridge(b)
+	return nil
+}
+
+// StopBridge ends the bridge a chat is part of, telling both chats why.
+func (c *TelegramCalls) StopBridge(chatID int64, reason string) error {
+	c.bridgeMu.Lock()
+	b, ok := c.bridges[chatID]
+	if ok {
+		if b.reason == "" {
+			b.reason = reason
+		}
+		for _, id := range b.chats {
+			delete(c.bridges, id)
+		}
+	}
+	c.bridgeMu.Unlock()
+	if !ok {
+		return errors.New("the chat is not bridged")
+	}
+	b.cancel()
+	return nil
+}
+
+// BridgedWith returns the chat a chat is bridged with, or 0 if it is not bridged.
+func (c *TelegramCalls) BridgedWith(chatID int64) int64 {
+	b := c.getBridge(chatID)
+	if b == nil {
+		return 0
+	}
+	if b.chats[0] == chatID {
+		return b.chats[1]
+	}
+	return b.chats[0]
+}
+
+// BridgeBothWays reports whether a chat's bridge relays audio in both directions.
+func (c *TelegramCalls) BridgeBothWays(chatID int64) bool {
+	b := c.getBridge(chatID)
+	return b != nil && len(b.relays) == 2
+}
+
+func (c *TelegramCalls) getBridge(chatID int64) *bridge {
+	c.bridgeMu.Lock()
+	defer c.bridgeMu.Unlock()
+	return c.bridges[chatID]
+}
+
+// separateAssistants makes sure the two chats of a bridge are served by different assistants,
+// since an assistant relaying a voice chat into itself would hear its own output. A chat
+// whose assistant is not in its voice chat is moved to another assistant.
+func (c *TelegramCalls) separateAssistants(from, to int64) error {
+	fromCall, fromIndex, err := c.GetGroupAssistant(from)
+	if err != nil {
+		return err
+	}
+	toCall, toIndex, err := c.GetGroupAssistant(to)
+	if err != nil {
+		return err
+	}
+	if fromIndex != toIndex {
+		return nil
+	}
+
+	other := -1
+	c.mu.RLock()
+	for index := range c.assistants {
+		if index != fromIndex {
+			other = index
+			break
+		}
+	}
+	c.mu.RUnlock()
+	if other == -1 {
+		return errors.New("bridging needs a separate assistant for each chat, but only one assistant is configured")
+	}
+
+	switch {
+	case toCall.binding.Calls()[to] == nil:
+		return db.Instance.SetAssistant(to, other)
+	case fromCall.binding.Calls()[from] == nil:
+		return db.Instance.SetAssistant(from, other)
+	}
+	return errors.New("both chats are served by the same assistant and it is in both voice chats; end playback in one of them and try again")
+}
+
+// ensureInCall makes sure a chat's assistant is in its voice chat, joining with a silent
+// external microphone if it is not.
+func (c *TelegramCalls) ensureInCall(bot *td.Client, chatID int64) (*Assistant, error) {
+	call, index, err := c.GetGroupAssistant(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if call.binding.Calls()[chatID] != nil {
+		return call, nil
+	}
+
+	if err = c.joinAssistant(bot, chatID, call, index); err != nil {
+		return nil, err
+	}
+	if err = call.Play(context.Background(), chatID, ntgcalls.MediaDescription{Microphone: mixAudioDescription()}); err != nil {
+		if classifyError(err) == errFatal {
+			return nil, fatalMessage(err)
+		}
+		return nil, err
+	}
+	_ = db.Instance.SetAssistant(chatID, index)
+	return call, nil
+}
+
+// bridgeFrames receives the audio of a voice chat's participants for the bridges relaying it.
+func (c *TelegramCalls) bridgeFrames(chatID int64, frames []ntgcalls.Frame) {
+	b := c.getBridge(chatID)
+	if b == nil {
+		return
+	}
+	for _, r := range b.relays {
+		if r.from != chatID {
+			continue
+		}
+		for _, frame := range frames {
+			r.audio.write(int64(frame.Ssrc), frame.Data)
+		}
+	}
+}
+
+// bridgeOutput receives a frame the bot plays in a chat, so the relays from that chat carry
+// it along with the participants' audio. It runs before bridged audio is mixed into the frame,
+// and the capture never includes the assistant's own output, so in a two-way bridge the audio
+// relayed into a chat is not relayed back out of it.
+func (c *TelegramCalls) bridgeOutput(chatID int64, frame []byte, sampleRate, channels int) {
+	b := c.getBridge(chatID)
+	if b == nil {
+		return
+	}
+	for _, r := range b.relays {
+		if r.from == chatID {
+			r.audio.write(ownSource, convertPCM(frame, sampleRate, channels, mixSampleRate, mixChannels))
+		}
+	}
+}
+
+// runBridge moves one frame through every relay each audioFrameDuration until the bridge is
+// stopped or one of the voice chats ends.
+func (c *TelegramCalls) runBridge(b *bridge) {
+	defer c.finishBridge(b)
+	ticker := time.NewTicker(audioFrameDuration)
+	defer ticker.Stop()
+
+	out := make([]byte, mixFrameSize)
+	for tick := 1; ; tick++ {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if tick%int(time.Second/audioFrameDuration) == 0 {
+			for _, r := range b.relays {
+				if r.fromCall.binding.Calls()[r.from] == nil || r.toCall.binding.Calls()[r.to] == nil {
+					_ = c.StopBridge(r.from, "a voice chat ended")
+					return
+				}
+			}
+		}
+
+		for _, r := range b.relays {
+			if err := c.relayFrame(r, out); err != nil {
+				logger.Warn("[bridge] Failed to relay audio", "from", r.from, "to", r.to, "error", err)
+				_ = c.StopBridge(r.from, "relaying the audio failed")
+				return
+			}
+		}
+	}
+}
+
+// relayFrame moves one frame of a relay's audio into the target chat. While the target plays
+// something, the audio is mixed into that stream; otherwise the relay feeds the call itself.
+func (c *TelegramCalls) relayFrame(r *relay, out []byte) error {
+	hasAudio := r.audio.next(out)
+	if c.playing(r.to) {
+		r.direct = false
+		if hasAudio {
+			c.addOverlaySource(r.to, r.from).write(r.from, out)
+		}
+		return nil
+	}
+
+	if !r.direct {
+		if err := r.toCall.Play(context.Background(), r.to, ntgcalls.MediaDescription{Microphone: mixAudioDescription()}); err != nil {
+			return err
+		}
+		r.direct = true
+	}
+	if err := r.toCall.binding.SendExternalFrame(r.to, ntgcalls.MicrophoneStream, out, ntgcalls.FrameData{AbsoluteCaptureTimestampMs: time.Now().UnixMilli()}); err != nil {
+		return err
+	}
+	c.recordOutput(r.to, out, mixSampleRate, mixChannels)
+	return nil
+}
+
+// finishBridge releases what a bridge held and tells both chats that it ended.
+func (c *TelegramCalls) finishBridge(b *bridge) {
+	for _, r := range b.relays {
+		c.releaseCapture(r.fromCall, r.from)
+		c.removeOverlaySource(r.to, r.from)
+	}
+	c.leaveIdle(b)
+
+	c.bridgeMu.Lock()
+	reason := b.reason
+	c.bridgeMu.Unlock()
+	for _, chatID := range b.chats {
+		_, _ = b.bot.SendTextMessage(chatID, "🔗 The voice chat bridge ended: "+reason, nil)
+	}
+}
+
+// leaveIdle leaves the voice chats of a bridge in which nothing is playing, which the bridge
+// either joined or kept open after their queue finished.
+func (c *TelegramCalls) leaveIdle(b *bridge) {
+	for _, chatID := range b.chats {
+		if c.playing(chatID) {
+			continue
+		}
+		call, _, err := c.GetGroupAssistant(chatID)
+		if err != nil || call.binding.Calls()[chatID] == nil {
+			continue
+		}
+		if err = c.Stop(chatID, false); err != nil {
+			logger.Warn("[bridge] Failed to leave the voice chat", "chatID", chatID, "error", err)
+		}
+	}
+}
+
+// playing reports whether a track is playing or queued in a chat.
+func (c *TelegramCalls) playing(chatID int64) bool {
+	if cache.ChatCache.GetPlayingTrack(chatID) != nil {
+		return true
+	}
+	_, streaming := c.getStream(chatID)
+	return streaming
+}
@@ -22,7 +22,6 @@ import "C"
 
 import (
 	"ashokshau/tgmusic/config"
-	"ashokshau/tgmusic/src/core"
 	"ashokshau/tgmusic/src/core/cache"
 	"ashokshau/tgmusic/src/core/db"
 	"ashokshau/tgmusic/src/utils"
@@ -31,10 +30,8 @@ import (
 	"crypto/rand"
 	"errors"
 	"fmt"
-	"html"
 	"log/slog"
 	"math/big"
-	"os"
 	"strings"
 
 	td "github.com/AshokShau/gotdbot"
@@ -102,40 +99,32 @@ func (c *TelegramCalls) GetGroupAssistant(chatID int64) (*Assistant, int, error)
 // playSong downloads and plays a single song. It sends a message to the chat to indicate the download status
 // and updates it with the song's information once playback begins.
 func (c *TelegramCalls) playSong(bot *td.Client, chatID int64, song *utils.CachedTrack) error {
+	// Work on a copy, so play-time state such as the ingest listener URL stays out of the queue.
+	track := *song
+	song = &track
+
 	reply, err := bot.SendTextMessage(chatID, fmt.Sprintf("Downloading %s...", song.Name), nil)
 	if err != nil {
 		slog.Info("[playSong] Failed to send message", "error", err)
 		return err
 	}
 
-	if err = c.downloadAndPrepareSong(bot, song, reply); err != nil {
-		return c.PlayNext(bot, chatID)
+	if err = c.downloadAndPrepareSong(bot, chatID, song, reply); err != nil {
+		cache.ChatCache.RemoveCurrentSong(chatID)
+		return c.playCurrent(bot, chatID)
 	}
 
-	if err = c.PlayMedia(bot, chatID, song.FilePath, song.IsVideo, ""); err != nil {
+	if err = c.PlayMedia(bot, chatID, song.FilePath, song.IsVideo); err != nil {
 		_, _ = reply.EditText(bot, err.Error(), &td.EditTextMessageOpts{ParseMode: "HTML", DisableWebPagePreview: true})
 		return nil
 	}
 
-	if song.Duration == 0 {
+	if !song.IsLive && song.Duration == 0 {
 		song.Duration = utils.GetMediaDuration(song.FilePath)
+		cache.ChatCache.SetTrackDuration(chatID, song.TrackID, song.Duration)
 	}
 
-	text := fmt.Sprintf(
-		"<u><b>| Started streaming</b></u>\n\n<b>Title:</b> <a href='%s'>%s</a>\n\n<b>Duration:</b> %s min\n<b>Requested by:</b> %s",
-		html.EscapeString(song.URL),
-		html.EscapeString(song.Name),
-		utils.SecToMin(song.Duration),
-		html.EscapeString(song.User),
-	)
-
-	_, err = reply.EditText(bot, text, &td.EditTextMessageOpts{
-		ReplyMarkup:           core.ControlButtons("play"),
-		ParseMode:             "HTML",
-		DisableWebPagePreview: true,
-	})
-
-	if err != nil {
+	if err = c.ShowNowPlaying(bot, chatID, reply); err != nil {
 		slog.Info("[playSong] Failed to edit message", "error", err)
 		return nil
 	}
@@ -152,6 +141,15 @@ func (c *TelegramCalls) Stop(chatId int64, banned bool) error {
 
 	cache.ChatCache.SetAutoplay(chatId, false)
 	cache.ChatCache.ClearChat(chatId)
+	c.streamsMu.Lock()
+	delete(c.streams, chatId)
+	c.streamsMu.Unlock()
+	c.stopStream(chatId)
+	c.ReleaseIngest(chatId)
+	_ = c.StopRecording(chatId, "the voice chat ended")
+	_ = c.StopBridge(chatId, "the voice chat ended")
+	c.closeNowPlaying(chatId)
+	liveTitles.Delete(chatId)
 	err = call.stopCall(chatId, banned)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
@@ -177,6 +175,9 @@ func (c *TelegramCalls) Pause(chatId int64) (bool, error) {
 		slog.Warn("[Pause] Failed to pause the call", "error", err, "index", index)
 		return res, fmt.Errorf("failed to pause: %w", err)
 	}
+	if s, ok := c.getStream(chatId); ok {
+		s.setPaused(true)
+	}
 	return res, err
 }
 
@@ -192,6 +193,9 @@ func (c *TelegramCalls) Resume(chatId int64) (bool, error) {
 		logger.Warn("Failed to resume the call", "error", err, "index", index)
 		return res, fmt.Errorf("failed to resume: %w", err)
 	}
+	if s, ok := c.getStream(chatId); ok {
+		s.setPaused(false)
+	}
 
 	return res, err
 }
@@ -208,6 +212,9 @@ func (c *TelegramCalls) Mute(chatId int64) (bool, error) {
 		logger.Warn("Failed to mute the call", "error", err, "index", index)
 		return res, fmt.Errorf("failed to mute: %w", err)
 	}
+	if s, ok := c.getStream(chatId); ok {
+		s.setMuted(true)
+	}
 
 	return res, err
 }
@@ -224,12 +231,20 @@ func (c *TelegramCalls) Unmute(chatId int64) (bool, error) {
 		logger.Warn("Failed to unmute the call", "error", err, "index", index)
 		return res, fmt.Errorf("failed to unmute: %w", err)
 	}
+	if s, ok := c.getStream(chatId); ok {
+		s.setMuted(false)
+	}
 
 	return res, err
 }
 
-// PlayedTime retrieves the elapsed time of the current playback in a voice chat.
+// PlayedTime retrieves the position of the current track in a voice chat, accounting
+// for seeks and for tempo changes made by the chat's effect and speed settings.
 func (c *TelegramCalls) PlayedTime(chatId int64) (uint64, error) {
+	if s, ok := c.getStream(chatId); ok {
+		return c.trackPosition(chatId, s.played()), nil
+	}
+
 	call, index, err := c.GetGroupAssistant(chatId)
 	if err != nil {
 		return 0, err
@@ -241,7 +256,25 @@ func (c *TelegramCalls) PlayedTime(chatId int64) (uint64, error) {
 		return 0, fmt.Errorf("failed to get played time: %w", err)
 	}
 
-	return _time, nil
+	return c.trackPosition(chatId, _time), nil
+}
+
+// ListenerCount returns how many users besides the assistant are in a chat's voice chat.
+func (c *TelegramCalls) ListenerCount(chatId int64) int {
+	call, _, err := c.GetGroupAssistant(chatId)
+	if err != nil {
+		return 0
+	}
+	return call.listenerCount(chatId)
+}
+
+// IsListener reports whether a user is in a chat's voice chat.
+func (c *TelegramCalls) IsListener(chatId, userId int64) bool {
+	call, _, err := c.GetGroupAssistant(chatId)
+	if err != nil {
+		return false
+	}
+	return call.isListener(chatId, userId)
 }
 
 // SeekStream jumps to a specific time in the current media stream.
@@ -250,25 +283,86 @@ func (c *TelegramCalls) SeekStream(bot *td.Client, chatID int64, filePath string
 		return errors.New("invalid seek position or duration. The position must be positive and the duration must be greater than 0")
 	}
 
-	isURL := urlRegex.MatchString(filePath)
-	_, err := os.Stat(filePath)
-	isFile := err == nil
+	if toSeek >= duration {
+		return errors.New("the seek position is beyond the end of the track")
+	}
+
+	// ffmpeg seeks on the input, so local files, URL streams and downloaded Telegram files
+	// seek alike; the stream state records the offset for PlayedTime.
+	return c.playRange(bot, chatID, filePath, isVideo, seekRange{start: toSeek, end: duration, resume: true})
+}
+
+// Restart replays the current track from its current position, so that changed effect or
+// speed settings take effect immediately.
+func (c *TelegramCalls) Restart(bot *td.Client, chatID int64) error {
+	song := cache.ChatCache.GetPlayingTrack(chatID)
+	if isIngest(song) {
+		listenURL, _, err := c.ReserveIngest(chatID)
+		if err != nil {
+			return err
+		}
+		return c.playRange(bot, chatID, listenURL, song.IsVideo, seekRange{resume: true})
+	}
+	if song == nil || song.FilePath == "" {
+		return errors.New("nothing is playing")
+	}
+
+	position, err := c.PlayedTime(chatID)
+	if err != nil {
+		return err
+	}
+
+	if song.Duration > 0 && position > 0 && int(position) < song.Duration {
+		return c.SeekStream(bot, chatID, song.FilePath, int(position), song.Duration, song.IsVideo)
+	}
+	return c.playRange(bot, chatID, song.FilePath, song.IsVideo, seekRange{resume: true})
+}
+
+// RestartIfAudible restarts the current track like Restart, unless playback is paused or
+// muted. Then the restart waits for ApplyPendingRestart, so a changed setting does not
+// resume playback by itself. It reports whether the restart was deferred.
+func (c *TelegramCalls) RestartIfAudible(bot *td.Client, chatID int64) (bool, error) {
+	if s, ok := c.getStream(chatID); ok && s.deferRestart() {
+		return true, nil
+	}
+	return false, c.Restart(bot, chatID)
+}
 
-	var ffmpegParams string
-	if isURL || !isFile {
-		ffmpegParams = fmt.Sprintf("-ss %d -i %s -to %d", toSeek, filePath, duration)
-	} else {
-		ffmpegParams = fmt.Sprintf("-ss %d -to %d", toSeek, duration)
+// ApplyPendingRestart restarts the current track if a setting changed while playback was
+// paused or muted and it is audible again.
+func (c *TelegramCalls) ApplyPendingRestart(bot *td.Client, chatID int64) error {
+	if s, ok := c.getStream(chatID); ok && s.takePendingRestart() {
+		return c.Restart(bot, chatID)
 	}
+	return nil
+}
 
-	return c.PlayMedia(bot, chatID, filePath, isVideo, ffmpegParams)
+// PlaybackMode returns the control buttons mode matching a chat's playback: "pause" while
+// paused, "mute" while muted and "play" otherwise.
+func (c *TelegramCalls) PlaybackMode(chatID int64) string {
+	s, ok := c.getStream(chatID)
+	if !ok {
+		return "play"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.paused:
+		return "pause"
+	case s.muted:
+		return "mute"
+	}
+	return "play"
 }
 
 // RegisterHandlers sets up the event handlers for the voice call client.
 func (c *TelegramCalls) RegisterHandlers(client *td.Client) {
 	c.startAutoLeave(context.Background(), client)
+	c.startPrefetcher(client)
+	c.startNowPlaying()
 
 	for _, call := range c.assistants {
+		call.OnFrame(c.onCallFrames)
 		call.OnStreamEnd(func(chatID int64, streamType ntgcalls.StreamType, device ntgcalls.StreamDevice) {
 			if streamType == ntgcalls.VideoStream {
 				return
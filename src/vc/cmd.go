@@ -2,76 +2,189 @@ package vc
 
 import (
 	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/utils"
 	"ashokshau/tgmusic/src/vc/ntgcalls"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
 var isURLRegex = regexp.MustCompile(`^https?://`)
 
-// getMediaDescription creates a media description for ntgcalls based on the provided file path, video status, and ffmpeg parameters.
-func getMediaDescription(filePath string, isVideo bool, chatId int64, ffmpegParameters string) ntgcalls.MediaDescription {
-	var duration int
-	track := cache.ChatCache.GetPlayingTrack(chatId)
-	if track != nil {
-		duration = track.Duration
+// seekRange is the part of a track a stream plays, in seconds. A zero end plays to the end.
+type seekRange struct {
+	start, end int
+	// resume marks a restart of the playing track, such as a seek, which continues the same
+	// play of it.
+	resume bool
+}
+
+// mediaSource describes the ffmpeg processes that decode a chat's stream. The processes are
+// started with an argument list, never through a shell, so paths and URLs need no quoting.
+type mediaSource struct {
+	// input is the file or URL passed to ffmpeg's -i. Local files are prefixed with "file:"
+	// so names that look like options or other protocols are read as plain files.
+	input string
+	// live marks an endless stream: ffmpeg reconnects when it drops, and restarts resume at
+	// the live edge instead of a track position.
+	live bool
+	// listen makes ffmpeg wait for a streamer to push to input, an RTMP or SRT listener URL.
+	// One process then decodes both audio and video, since only one can accept the connection.
+	listen bool
+	// seek and end are the track positions in seconds the stream starts and stops at; end is 0
+	// to play to the end of the input.
+	seek, end int
+	// tempo is how many seconds of the track play per second of the stream.
+	tempo float64
+
+	audioFilter  string
+	sampleRate   int
+	channelCount int
+
+	video bool
+	// videoDevice is the camera or the screen, depending on the chat's video mode.
+	videoDevice ntgcalls.StreamDevice
+	videoFilter string
+	width       int
+	height      int
+	fps         int
+}
+
+// inputArgs returns the ffmpeg options that open the input at the given track position.
+func (s *mediaSource) inputArgs(seek int) []string {
+	args := []string{"-nostdin", "-hide_banner", "-v", "error"}
+	switch {
+	case s.listen && strings.HasPrefix(s.input, "rtmp://"):
+		args = append(args, "-listen", "1")
+	case s.live && !s.listen:
+		args = append(args, "-reconnect", "1", "-reconnect_at_eof", "1", "-reconnect_streamed", "1", "-reconnect_delay_max", "2")
 	}
+	if seek > 0 {
+		args = append(args, "-ss", strconv.Itoa(seek))
+	}
+	if s.end > 0 {
+		args = append(args, "-to", strconv.Itoa(s.end))
+	}
+	return append(args, "-i", s.input)
+}
 
-	audioDescription := &ntgcalls.AudioDescription{
-		MediaSource:  ntgcalls.MediaSourceShell,
-		SampleRate:   48000,
-		ChannelCount: 2,
+// audioOutput returns the ffmpeg output options that write the audio as raw PCM to output.
+func (s *mediaSource) audioOutput(output string) []string {
+	var args []string
+	if s.audioFilter != "" {
+		args = append(args, "-filter:a", s.audioFilter)
 	}
+	return append(args,
+		"-f", "s16le",
+		"-ac", strconv.Itoa(s.channelCount),
+		"-ar", strconv.Itoa(s.sampleRate),
+		output,
+	)
+}
 
-	quotedPath := fmt.Sprintf("\"%s\"", filePath)
-	isURL := isURLRegex.MatchString(filePath)
-	isLive := isURL && duration == 0
+// videoOutput returns the ffmpeg output options that write the video as raw YUV420 frames to output.
+func (s *mediaSource) videoOutput(output string) []string {
+	return []string{
+		"-f", "rawvideo",
+		"-r", strconv.Itoa(s.fps),
+		"-pix_fmt", "yuv420p",
+		"-vf", s.videoFilter,
+		output,
+	}
+}
 
-	var audioCmd strings.Builder
-	audioCmd.WriteString("ffmpeg ")
+// audioArgs returns the ffmpeg arguments that decode the audio to raw PCM on stdout.
+func (s *mediaSource) audioArgs(seek int) []string {
+	return append(append(s.inputArgs(seek), "-vn"), s.audioOutput("pipe:1")...)
+}
 
-	if isLive {
-		audioCmd.WriteString("-reconnect 1 -reconnect_at_eof 1 -reconnect_streamed 1 -reconnect_delay_max 2 ")
-	}
+// videoArgs returns the ffmpeg arguments that decode the video to raw YUV420 frames on stdout.
+func (s *mediaSource) videoArgs(seek int) []string {
+	return append(append(s.inputArgs(seek), "-an"), s.videoOutput("pipe:1")...)
+}
 
-	var seekFlags, filterFlags string
-	if ffmpegParameters != "" {
-		if strings.Contains(ffmpegParameters, "filter:") {
-			filterFlags = ffmpegParameters
-		} else {
-			seekFlags = ffmpegParameters
-		}
+// listenArgs returns the ffmpeg arguments that wait for a streamer and decode the audio to
+// stdout and, for video, the video to file descriptor 3. Either may be missing from the push.
+func (s *mediaSource) listenArgs() []string {
+	args := append(s.inputArgs(0), "-map", "0:a:0?")
+	args = append(args, s.audioOutput("pipe:1")...)
+	if s.video {
+		args = append(args, "-map", "0:v:0?")
+		args = append(args, s.videoOutput("pipe:3")...)
 	}
+	return args
+}
 
-	if seekFlags != "" {
-		audioCmd.WriteString(seekFlags + " ")
+// getMediaDescription creates a media description for ntgcalls based on the provided file path, video status and
+// the part of the track to play, together with the ffmpeg source that feeds it.
+// Video goes out as the camera or as a screen share according to the chat's video mode.
+// The chat's quality profile sets the stream parameters, and its effect, speed, volume and loudness
+// normalization settings are applied as audio filters, with the video retimed to match.
+func getMediaDescription(filePath string, isVideo bool, chatId int64, seek seekRange) (ntgcalls.MediaDescription, *mediaSource) {
+	var trackID string
+	var live, ingest bool
+	track := cache.ChatCache.GetPlayingTrack(chatId)
+	if track != nil {
+		trackID = track.TrackID
+		live = track.IsLive
+		ingest = track.Platform == utils.Ingest
 	}
 
-	audioCmd.WriteString("-i " + quotedPath + " ")
-
-	if filterFlags != "" {
-		audioCmd.WriteString(filterFlags + " ")
+	profile := utils.GetQualityProfile(db.Instance.GetQuality(chatId))
+	isURL := isURLRegex.MatchString(filePath) || ingest
+	source := &mediaSource{
+		input:        filePath,
+		live:         live && isURL,
+		listen:       ingest,
+		sampleRate:   profile.SampleRate,
+		channelCount: profile.ChannelCount,
+		seek:         seek.start,
+		end:          seek.end,
+		video:        isVideo,
+	}
+	if !isURL {
+		source.input = "file:" + filePath
 	}
 
-	audioCmd.WriteString(fmt.Sprintf(
-		"-f s16le -ac %d -ar %d -v quiet pipe:1",
-		audioDescription.ChannelCount,
-		audioDescription.SampleRate,
-	))
+	audioFilter, tempo := chatAudioFilter(chatId)
+	if db.Instance.GetNormalize(chatId) {
+		// Normalize before the other filters so the volume setting stays relative to the target level.
+		norm := loudnormFilter(trackID, filePath, isURL)
+		if audioFilter != "" {
+			norm += "," + audioFilter
+		}
+		audioFilter = norm
+	}
+	source.audioFilter = audioFilter
+	source.tempo = tempo
 
-	audioDescription.Input = audioCmd.String()
+	audioDescription := &ntgcalls.AudioDescription{
+		MediaSource:  ntgcalls.MediaSourceExternal,
+		SampleRate:   uint32(source.sampleRate),
+		ChannelCount: uint8(source.channelCount),
+	}
 
 	if !isVideo {
 		return ntgcalls.MediaDescription{
 			Microphone: audioDescription,
-		}
+		}, source
 	}
 
-	originalWidth, originalHeight := getVideoDimensions(filePath)
+	width := profile.MaxWidth
+	height := profile.MaxHeight
 
-	width := 1280
-	height := 720
+	if ingest {
+		// The pushed resolution is unknown until a streamer connects, so the video is fitted
+		// into the profile's frame.
+		source.width, source.height, source.fps = width, height, profile.Fps
+		source.videoFilter = fmt.Sprintf("%sscale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2",
+			videoTempoFilter(tempo), width, height, width, height)
+		return videoMediaDescription(chatId, audioDescription, source)
+	}
+
+	originalWidth, originalHeight := getVideoDimensions(source.input)
 
 	if originalWidth > 0 && originalHeight > 0 {
 		ratio := float64(originalWidth) / float64(originalHeight)
@@ -94,41 +207,32 @@ func getMediaDescription(filePath string, isVideo bool, chatId int64, ffmpegPara
 		height = newH
 	}
 
-	videoDescription := &ntgcalls.VideoDescription{
-		MediaSource: ntgcalls.MediaSourceShell,
-		Width:       int16(width),
-		Height:      int16(height),
-		Fps:         30,
-	}
-
-	var videoCmd strings.Builder
-	videoCmd.WriteString("ffmpeg ")
-
-	if isLive {
-		videoCmd.WriteString("-reconnect 1 -reconnect_at_eof 1 -reconnect_streamed 1 -reconnect_delay_max 2 ")
-	}
+	source.width, source.height, source.fps = width, height, profile.Fps
+	source.videoFilter = fmt.Sprintf("%sscale=%d:%d", videoTempoFilter(tempo), width, height)
+	return videoMediaDescription(chatId, audioDescription, source)
+}
 
-	if seekFlags != "" {
-		videoCmd.WriteString(seekFlags + " ")
+// videoMediaDescription completes the media description of a video source, sending the video
+// as the camera or as a screen share according to the chat's video mode.
+func videoMediaDescription(chatId int64, audioDescription *ntgcalls.AudioDescription, source *mediaSource) (ntgcalls.MediaDescription, *mediaSource) {
+	videoDescription := &ntgcalls.VideoDescription{
+		MediaSource: ntgcalls.MediaSourceExternal,
+		Width:       int16(source.width),
+		Height:      int16(source.height),
+		Fps:         uint8(source.fps),
 	}
 
-	videoCmd.WriteString("-i " + quotedPath + " ")
-
-	if filterFlags != "" {
-		videoCmd.WriteString(filterFlags + " ")
+	if db.Instance.GetVideoMode(chatId) == utils.VideoModeScreen {
+		source.videoDevice = ntgcalls.ScreenStream
+		return ntgcalls.MediaDescription{
+			Microphone: audioDescription,
+			Screen:     videoDescription,
+		}, source
 	}
 
-	videoCmd.WriteString(fmt.Sprintf(
-		"-f rawvideo -r %d -pix_fmt yuv420p -vf scale=%d:%d -v quiet pipe:1",
-		videoDescription.Fps,
-		videoDescription.Width,
-		videoDescription.Height,
-	))
-
-	videoDescription.Input = videoCmd.String()
-
+	source.videoDevice = ntgcalls.CameraStream
 	return ntgcalls.MediaDescription{
 		Microphone: audioDescription,
 		Camera:     videoDescription,
-	}
+	}, source
 }
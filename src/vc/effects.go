@@ -0,0 +1,134 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/src/core/db"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// audioEffect is an ffmpeg audio filter chain that can be applied to a chat's streams.
+type audioEffect struct {
+	filter string
+	// tempo is how much faster than the source the effect plays the audio.
+	tempo float64
+}
+
+// audioEffects are the presets available through /effect. Nightcore and slowed change the
+// sample rate to shift pitch and tempo together.
+var audioEffects = map[string]audioEffect{
+	"bass":      {filter: "bass=g=10:f=110:w=0.6", tempo: 1},
+	"nightcore": {filter: "aresample=48000,asetrate=60000,aresample=48000", tempo: 1.25},
+	"slowed":    {filter: "aresample=48000,asetrate=38400,aresample=48000,aecho=0.8:0.88:60:0.4", tempo: 0.8},
+	"8d":        {filter: "apulsator=hz=0.125", tempo: 1},
+}
+
+// Playback speeds accepted by /speed. atempo handles this range in a single pass.
+const (
+	MinSpeed = 0.5
+	MaxSpeed = 2.0
+)
+
+// Volume levels in percent accepted by /volume.
+const (
+	MinVolume = 1
+	MaxVolume = 200
+)
+
+// EffectNames returns the names of the available audio effects, sorted.
+func EffectNames() []string {
+	names := make([]string, 0, len(audioEffects))
+	for name := range audioEffects {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// HasEffect reports whether name is an available audio effect.
+func HasEffect(name string) bool {
+	_, ok := audioEffects[name]
+	return ok
+}
+
+// chatAudioFilter returns the ffmpeg audio filter chain for a chat's effect, speed and
+// volume settings, and the resulting tempo relative to the source.
+func chatAudioFilter(chatID int64) (string, float64) {
+	var filters []string
+	tempo := 1.0
+
+	if effect, ok := audioEffects[db.Instance.GetEffect(chatID)]; ok {
+		filters = append(filters, effect.filter)
+		tempo *= effect.tempo
+	}
+	if speed := db.Instance.GetSpeed(chatID); speed != 1 {
+		filters = append(filters, "atempo="+strconv.FormatFloat(speed, 'f', -1, 64))
+		tempo *= speed
+	}
+	if volume := db.Instance.GetVolume(chatID); volume != 100 {
+		filters = append(filters, "volume="+strconv.FormatFloat(float64(volume)/100, 'f', -1, 64))
+	}
+	return strings.Join(filters, ","), tempo
+}
+
+// videoTempoFilter returns the video filter that keeps the picture in sync with audio
+// played at the given tempo, or "" at normal speed.
+func videoTempoFilter(tempo float64) string {
+	if tempo == 1 {
+		return ""
+	}
+	return fmt.Sprintf("setpts=PTS/%s,", strconv.FormatFloat(tempo, 'f', -1, 64))
+}
+
+// streamState describes how the stream playing in a chat maps to the track's timeline.
+type streamState struct {
+	// offset is the track position in seconds the stream started at.
+	offset int
+	// started is when the current play of the track began. Restarts such as seeks keep it.
+	started time.Time
+	// tempo is how many seconds of the track play per second of the stream.
+	tempo float64
+}
+
+// setStreamState records the timeline of a stream that was just started for the given part of the track.
+func (c *TelegramCalls) setStreamState(chatID int64, seek seekRange) {
+	state := streamState{offset: seek.start, started: time.Now()}
+	_, state.tempo = chatAudioFilter(chatID)
+
+	c.streamsMu.Lock()
+	if prev, ok := c.streams[chatID]; ok && seek.resume {
+		state.started = prev.started
+	}
+	c.streams[chatID] = state
+	c.streamsMu.Unlock()
+}
+
+// PlayStarted returns when the current play of a chat's track began. It identifies that play:
+// it changes when the track is played again, but not when it is seeked or restarted.
+func (c *TelegramCalls) PlayStarted(chatID int64) (time.Time, bool) {
+	c.streamsMu.Lock()
+	defer c.streamsMu.Unlock()
+	state, ok := c.streams[chatID]
+	return state.started, ok
+}
+
+// trackPosition converts the seconds a stream has played into a position in the track.
+func (c *TelegramCalls) trackPosition(chatID int64, played uint64) uint64 {
+	c.streamsMu.Lock()
+	state, ok := c.streams[chatID]
+	c.streamsMu.Unlock()
+	if !ok {
+		return played
+	}
+	return uint64(float64(state.offset) + float64(played)*state.tempo)
+}
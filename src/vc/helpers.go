@@ -10,10 +10,12 @@ package vc
 
 import (
 	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
 	"ashokshau/tgmusic/src/core/dl"
 	"ashokshau/tgmusic/src/utils"
 	"context"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"math/big"
 	"os/exec"
@@ -26,23 +28,44 @@ import (
 )
 
 // downloadAndPrepareSong handles the download and preparation of a song for playback.
+// A song that is already being prefetched is taken from the prefetch instead of downloaded again.
 // It returns an error if the download or preparation fails.
-func (c *TelegramCalls) downloadAndPrepareSong(bot *td.Client, song *utils.CachedTrack, reply *td.Message) error {
+func (c *TelegramCalls) downloadAndPrepareSong(bot *td.Client, chatID int64, song *utils.CachedTrack, reply *td.Message) error {
+	if isIngest(song) {
+		listenURL, _, err := c.ReserveIngest(chatID)
+		if err != nil {
+			_, _ = reply.EditText(bot, fmt.Sprintf("⚠️ %s. Skipping live ingest...", err.Error()), nil)
+			return err
+		}
+		song.FilePath = listenURL
+		return nil
+	}
+
 	if song.FilePath != "" {
 		return nil
 	}
 
-	dlPath, err := dl.DownloadCachedTrack(song, bot)
+	if path := c.awaitPrefetch(chatID, song.TrackID); path != "" {
+		song.FilePath = path
+		return nil
+	}
+
+	if song.Quality == "" {
+		song.Quality = db.Instance.GetQuality(chatID)
+	}
+	dlPath, err := dl.DownloadCachedTrack(context.Background(), song, bot)
 	song.FilePath = dlPath
 	if err != nil || song.FilePath == "" {
 		_, _ = reply.EditText(bot, "⚠️ Download failed. Skipping track...", nil)
 		return err
 	}
 
+	cache.ChatCache.SetTrackFile(chatID, song.TrackID, song.FilePath)
 	return nil
 }
 
-// PlayNext plays the next song in the queue, handles looping, and notifies the chat when the queue is finished.
+// PlayNext plays the next song in the queue, handles looping and the chat's repeat mode,
+// and notifies the chat when the queue is finished.
 func (c *TelegramCalls) PlayNext(bot *td.Client, chatID int64) error {
 	loop := cache.ChatCache.GetLoopCount(chatID)
 	if loop > 0 {
@@ -52,9 +75,41 @@ func (c *TelegramCalls) PlayNext(bot *td.Client, chatID int64) error {
 		}
 	}
 
-	cache.ChatCache.RemoveCurrentSong(chatID)
-	if nextSong := cache.ChatCache.GetPlayingTrack(chatID); nextSong != nil {
-		return c.playSong(bot, chatID, nextSong)
+	return c.advance(bot, chatID, false)
+}
+
+// Skip ends the current track and plays the next one. Unlike PlayNext it ignores the
+// track's loop count and does not replay the track when the chat repeats single tracks.
+func (c *TelegramCalls) Skip(bot *td.Client, chatID int64) error {
+	cache.ChatCache.SetLoopCount(chatID, 0)
+	return c.advance(bot, chatID, true)
+}
+
+// PlayPrevious replays the most recently finished track. The track that was playing
+// moves back to the front of the upcoming queue.
+func (c *TelegramCalls) PlayPrevious(bot *td.Client, chatID int64) error {
+	song := cache.ChatCache.PlayPrevious(chatID)
+	if song == nil {
+		return errors.New("no previously played track")
+	}
+	return c.playSong(bot, chatID, song)
+}
+
+// advance moves the queue forward and plays the next track. The chat's ingest port is freed
+// once a live ingest is no longer playing.
+func (c *TelegramCalls) advance(bot *td.Client, chatID int64, skip bool) error {
+	wasIngest := isIngest(cache.ChatCache.GetPlayingTrack(chatID))
+	if next := cache.ChatCache.AdvanceQueue(chatID, skip); wasIngest && !isIngest(next) {
+		c.ReleaseIngest(chatID)
+	}
+	return c.playCurrent(bot, chatID)
+}
+
+// playCurrent plays the track at the head of the queue, falling back to autoplay
+// or finishing playback when the queue is empty.
+func (c *TelegramCalls) playCurrent(bot *td.Client, chatID int64) error {
+	if song := cache.ChatCache.GetPlayingTrack(chatID); song != nil {
+		return c.playSong(bot, chatID, song)
 	}
 
 	lastSong := cache.ChatCache.GetLastYouTubeTrack(chatID)
@@ -75,13 +130,26 @@ func (c *TelegramCalls) handleAutoplay(bot *td.Client, chatID int64, lastSong *u
 		return c.handleNoSong(bot, chatID)
 	}
 
+	recent := map[string]bool{lastSong.TrackID: true}
+	for _, t := range cache.ChatCache.GetHistory(chatID) {
+		recent[t.TrackID] = true
+	}
+
 	var candidates []utils.MusicTrack
 	for _, t := range tracks.Results {
-		if t.Id != lastSong.TrackID {
+		if !recent[t.Id] {
 			candidates = append(candidates, t)
 		}
 	}
 
+	if len(candidates) == 0 {
+		for _, t := range tracks.Results {
+			if t.Id != lastSong.TrackID {
+				candidates = append(candidates, t)
+			}
+		}
+	}
+
 	if len(candidates) == 0 {
 		return c.handleNoSong(bot, chatID)
 	}
@@ -105,8 +173,21 @@ func (c *TelegramCalls) handleAutoplay(bot *td.Client, chatID int64, lastSong *u
 }
 
 // handleNoSong manages the situation where there are no more songs in the queue by stopping the playback
-// and sending a notification to the chat.
+// and sending a notification to the chat. A 24/7 chat refills its queue instead, and a bridged
+// chat stays in the voice chat.
 func (c *TelegramCalls) handleNoSong(bot *td.Client, chatID int64) error {
+	if ok, err := c.refillAlwaysOn(bot, chatID); ok {
+		return err
+	}
+	if c.BridgedWith(chatID) != 0 {
+		cache.ChatCache.ClearChat(chatID)
+		c.streamsMu.Lock()
+		delete(c.streams, chatID)
+		c.streamsMu.Unlock()
+		c.stopStream(chatID)
+		_, _ = bot.SendTextMessage(chatID, "🎵 Queue finished. The voice chat bridge stays connected.", nil)
+		return nil
+	}
 	_ = c.Stop(chatID, false)
 	_, _ = bot.SendTextMessage(chatID, "🎵 Queue finished. Add more songs with /play.", nil)
 	return nil
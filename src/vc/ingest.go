@@ -0,0 +1,98 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/config"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/utils"
+	"errors"
+	"fmt"
+)
+
+// IngestEndpoint is where a streamer pushes a live stream for a chat.
+type IngestEndpoint struct {
+	// URL is the full address to push to, stream key included.
+	URL string
+	// Server and Key split URL the way streaming software such as OBS asks for it.
+	Server string
+	Key    string
+}
+
+// IngestEnabled reports whether live ingest is configured.
+func IngestEnabled() bool {
+	return len(config.IngestPorts) > 0 && config.IngestHost != ""
+}
+
+// ReserveIngest reserves an ingest port for a chat and returns the ffmpeg listener URL to
+// play together with the endpoint to give the streamer. A chat keeps its port until
+// ReleaseIngest, so calling it again returns the same port. The URLs carry the chat's current
+// stream key, so they are built when needed and never stored with the queued track.
+func (c *TelegramCalls) ReserveIngest(chatID int64) (string, IngestEndpoint, error) {
+	if !IngestEnabled() {
+		return "", IngestEndpoint{}, errors.New("live ingest is not enabled")
+	}
+
+	key := db.Instance.GetIngestKey(chatID)
+	if key == "" {
+		return "", IngestEndpoint{}, errors.New("no stream key is set for this chat")
+	}
+
+	c.ingestMu.Lock()
+	defer c.ingestMu.Unlock()
+
+	port, ok := c.ingestPorts[chatID]
+	if !ok {
+		used := make(map[int]bool, len(c.ingestPorts))
+		for _, p := range c.ingestPorts {
+			used[p] = true
+		}
+		for _, p := range config.IngestPorts {
+			if !used[p] {
+				port, ok = p, true
+				break
+			}
+		}
+		if !ok {
+			return "", IngestEndpoint{}, errors.New("all ingest ports are in use, try again later")
+		}
+		c.ingestPorts[chatID] = port
+	}
+
+	listenURL, endpoint := ingestURLs(port, key)
+	return listenURL, endpoint, nil
+}
+
+// isIngest reports whether a track is a live ingest.
+func isIngest(track *utils.CachedTrack) bool {
+	return track != nil && track.Platform == utils.Ingest
+}
+
+// ReleaseIngest frees the ingest port reserved for a chat.
+func (c *TelegramCalls) ReleaseIngest(chatID int64) {
+	c.ingestMu.Lock()
+	defer c.ingestMu.Unlock()
+	delete(c.ingestPorts, chatID)
+}
+
+// ingestURLs returns the listener URL and the streamer's endpoint for a port and stream key.
+// SRT rejects connections without the key as passphrase. ffmpeg's RTMP listener only warns
+// about a mismatched application or stream name and accepts the push anyway, so over RTMP
+// the key in the address authenticates nothing: anyone who can reach the port can stream.
+func ingestURLs(port int, key string) (string, IngestEndpoint) {
+	if config.IngestProtocol == "srt" {
+		server := fmt.Sprintf("srt://%s:%d", config.IngestHost, port)
+		return fmt.Sprintf("srt://0.0.0.0:%d?mode=listener&passphrase=%s", port, key),
+			IngestEndpoint{URL: server + "?passphrase=" + key, Server: server, Key: key}
+	}
+
+	server := fmt.Sprintf("rtmp://%s:%d/%s", config.IngestHost, port, key)
+	return fmt.Sprintf("rtmp://0.0.0.0:%d/%s/live", port, key),
+		IngestEndpoint{URL: server + "/live", Server: server, Key: "live"}
+}
@@ -0,0 +1,59 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/radio"
+	"ashokshau/tgmusic/src/utils"
+	"fmt"
+	"html"
+	"strings"
+	"sync"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// liveTitle is the last now-playing title announced for a chat's live track.
+type liveTitle struct {
+	trackID string
+	title   string
+}
+
+// liveTitles holds a liveTitle per chat, so a stream restarted by a settings change does not
+// announce the same title again.
+var liveTitles sync.Map
+
+// isRadioStream reports whether a live track plays an http(s) stream that may publish station
+// metadata. Ingests and YouTube live streams do not.
+func isRadioStream(track *utils.CachedTrack, streamURL string) bool {
+	return track != nil && track.Platform == utils.DirectLink &&
+		(strings.HasPrefix(streamURL, "http://") || strings.HasPrefix(streamURL, "https://"))
+}
+
+// watchLiveMetadata announces the now-playing titles a live stream publishes for as long as
+// the stream plays.
+func watchLiveMetadata(bot *td.Client, s *externalStream, streamURL string) {
+	track := cache.ChatCache.GetPlayingTrack(s.chatID)
+	if track == nil {
+		return
+	}
+
+	radio.WatchMetadata(s.ctx, streamURL, func(title string) {
+		if last, ok := liveTitles.Load(s.chatID); ok && last.(liveTitle) == (liveTitle{track.TrackID, title}) {
+			return
+		}
+		liveTitles.Store(s.chatID, liveTitle{track.TrackID, title})
+
+		text := fmt.Sprintf("📻 <b>Now on air:</b> %s\n<b>Station:</b> %s", html.EscapeString(title), html.EscapeString(track.Name))
+		if _, err := bot.SendTextMessage(s.chatID, text, &td.SendTextMessageOpts{DisableWebPagePreview: true, ParseMode: "HTML"}); err != nil {
+			logger.Warn("[live] Failed to send the now playing title", "chatID", s.chatID, "error", err)
+		}
+	})
+}
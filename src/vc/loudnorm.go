@@ -0,0 +1,109 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/src/core/cache"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// EBU R128 targets used for loudness normalization: integrated loudness in LUFS,
+// true peak in dBTP and loudness range in LU.
+const (
+	loudnessTarget   = -16.0
+	truePeakTarget   = -1.5
+	loudnessRangeMax = 11.0
+)
+
+// loudnessTimeout bounds the measurement pass over a single track.
+const loudnessTimeout = 2 * time.Minute
+
+// loudnessStats are the values measured by the first loudnorm pass.
+type loudnessStats struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
+
+var (
+	// loudnessCache holds measured loudness values keyed by TrackID.
+	loudnessCache = cache.NewCache[loudnessStats](24 * time.Hour)
+	// loudnessPending holds the TrackIDs currently being measured.
+	loudnessPending sync.Map
+)
+
+// loudnormFilter returns the loudnorm filter for a track. Tracks that have been measured
+// get the precise two-pass linear filter; others get the dynamic single-pass filter and,
+// when the file is local, are measured in the background for the next time they play.
+func loudnormFilter(trackID, filePath string, isURL bool) string {
+	base := fmt.Sprintf("loudnorm=I=%g:TP=%g:LRA=%g", loudnessTarget, truePeakTarget, loudnessRangeMax)
+	if trackID == "" {
+		return base
+	}
+
+	if stats, ok := loudnessCache.Get(trackID); ok {
+		return fmt.Sprintf("%s:measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:offset=%s:linear=true",
+			base, stats.InputI, stats.InputTP, stats.InputLRA, stats.InputThresh, stats.TargetOffset)
+	}
+
+	if !isURL {
+		if _, busy := loudnessPending.LoadOrStore(trackID, struct{}{}); !busy {
+			go func() {
+				defer loudnessPending.Delete(trackID)
+				stats, err := measureLoudness(filePath)
+				if err != nil {
+					logger.Warn("[loudnorm] Failed to measure loudness", "trackID", trackID, "error", err)
+					return
+				}
+				loudnessCache.Set(trackID, stats)
+			}()
+		}
+	}
+	return base
+}
+
+// measureLoudness runs the first loudnorm pass over a local file and parses the JSON summary
+// ffmpeg prints at the end of its log.
+func measureLoudness(filePath string) (loudnessStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), loudnessTimeout)
+	defer cancel()
+
+	filter := fmt.Sprintf("loudnorm=I=%g:TP=%g:LRA=%g:print_format=json", loudnessTarget, truePeakTarget, loudnessRangeMax)
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-hide_banner", "-nostats", "-i", "file:"+filePath, "-vn", "-af", filter, "-f", "null", "-")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return loudnessStats{}, err
+	}
+
+	out := stderr.Bytes()
+	start := bytes.LastIndexByte(out, '{')
+	end := bytes.LastIndexByte(out, '}')
+	if start < 0 || end < start {
+		return loudnessStats{}, errors.New("no loudnorm summary in ffmpeg output")
+	}
+
+	var stats loudnessStats
+	if err := json.Unmarshal(out[start:end+1], &stats); err != nil {
+		return loudnessStats{}, err
+	}
+	if stats.InputI == "" || stats.InputI == "-inf" {
+		return loudnessStats{}, errors.New("track is silent")
+	}
+	return stats, nil
+}
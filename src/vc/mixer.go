@@ -0,0 +1,232 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/src/vc/ntgcalls"
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// mixSampleRate and mixChannels are the PCM format audio captured from calls is mixed in.
+	mixSampleRate = 48000
+	mixChannels   = 2
+	// mixFrameSize is the size of one audioFrameDuration of PCM in the mix format.
+	mixFrameSize = mixSampleRate * mixChannels * 2 * int(audioFrameDuration/time.Millisecond) / 1000
+	// maxMixBacklog is how many frames are buffered per source before the oldest are dropped.
+	maxMixBacklog = 50
+)
+
+// pcmMixer buffers PCM in the mix format from several sources and mixes it one frame at a
+// time. Sources are identified by an int64, such as a participant's SSRC or a chat ID.
+type pcmMixer struct {
+	mu      sync.Mutex
+	sources map[int64][]byte
+	sum     []int32
+}
+
+func newPCMMixer() *pcmMixer {
+	return &pcmMixer{sources: make(map[int64][]byte), sum: make([]int32, mixFrameSize/2)}
+}
+
+// write buffers PCM from a source, dropping its oldest audio beyond maxMixBacklog frames so
+// a source that is not being mixed cannot grow without bound.
+func (m *pcmMixer) write(source int64, pcm []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	buf := append(m.sources[source], pcm...)
+	if excess := len(buf) - maxMixBacklog*mixFrameSize; excess > 0 {
+		drop := (excess + mixFrameSize - 1) / mixFrameSize * mixFrameSize
+		buf = buf[min(drop, len(buf)):]
+	}
+	m.sources[source] = buf
+}
+
+// remove drops a source and its buffered audio.
+func (m *pcmMixer) remove(source int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sources, source)
+}
+
+// next mixes the next frame of every source that has a full frame buffered into out, which
+// must be mixFrameSize long. It reports whether any source had audio; out is silence otherwise.
+func (m *pcmMixer) next(out []byte) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clear(m.sum)
+	mixed := false
+	for source, pcm := range m.sources {
+		if len(pcm) < mixFrameSize {
+			continue
+		}
+		addSamples(m.sum, pcm[:mixFrameSize])
+		m.sources[source] = pcm[mixFrameSize:]
+		mixed = true
+	}
+	clampSamples(out, m.sum)
+	return mixed
+}
+
+// addSamples adds 16-bit little-endian samples to sum.
+func addSamples(sum []int32, pcm []byte) {
+	for i := range sum {
+		sum[i] += int32(int16(binary.LittleEndian.Uint16(pcm[i*2:])))
+	}
+}
+
+// clampSamples writes sum to out as 16-bit little-endian samples, clipping values that overflow.
+func clampSamples(out []byte, sum []int32) {
+	for i, v := range sum {
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(int16(max(math.MinInt16, min(math.MaxInt16, v)))))
+	}
+}
+
+// mixInto adds the 16-bit samples of pcm to frame, clipping values that overflow.
+func mixInto(frame, pcm []byte) {
+	for i := 0; i+1 < len(frame) && i+1 < len(pcm); i += 2 {
+		v := int32(int16(binary.LittleEndian.Uint16(frame[i:]))) + int32(int16(binary.LittleEndian.Uint16(pcm[i:])))
+		binary.LittleEndian.PutUint16(frame[i:], uint16(int16(max(math.MinInt16, min(math.MaxInt16, v)))))
+	}
+}
+
+// convertPCM converts 16-bit PCM between sample rates and channel counts by picking the
+// nearest sample, which is exact for the rates the quality profiles use.
+func convertPCM(pcm []byte, fromRate, fromChannels, toRate, toChannels int) []byte {
+	if fromRate == toRate && fromChannels == toChannels {
+		return pcm
+	}
+
+	frames := len(pcm) / (2 * fromChannels)
+	outFrames := frames * toRate / fromRate
+	out := make([]byte, outFrames*toChannels*2)
+	for i := 0; i < outFrames; i++ {
+		src := i * fromRate / toRate * fromChannels * 2
+		for ch := 0; ch < toChannels; ch++ {
+			if fromChannels == 2 && toChannels == 1 {
+				// Downmix instead of dropping the right channel.
+				l := int32(int16(binary.LittleEndian.Uint16(pcm[src:])))
+				r := int32(int16(binary.LittleEndian.Uint16(pcm[src+2:])))
+				binary.LittleEndian.PutUint16(out[i*2:], uint16(int16((l+r)/2)))
+				continue
+			}
+			off := src + min(ch, fromChannels-1)*2
+			copy(out[(i*toChannels+ch)*2:], pcm[off:off+2])
+		}
+	}
+	return out
+}
+
+// mixAudioDescription describes external audio in the mix format.
+func mixAudioDescription() *ntgcalls.AudioDescription {
+	return &ntgcalls.AudioDescription{
+		MediaSource:  ntgcalls.MediaSourceExternal,
+		SampleRate:   mixSampleRate,
+		ChannelCount: mixChannels,
+	}
+}
+
+// acquireCapture makes ntgcalls deliver the audio of a chat's participants in the mix format
+// through the assistant's frame callback. Captures are counted, since a recording and a
+// bridge may need the same chat's audio.
+func (c *TelegramCalls) acquireCapture(call *Assistant, chatID int64) error {
+	c.captureMu.Lock()
+	defer c.captureMu.Unlock()
+	if c.captures[chatID] == 0 {
+		if err := call.binding.SetStreamSources(chatID, ntgcalls.PlaybackStream, ntgcalls.MediaDescription{Speaker: mixAudioDescription()}); err != nil {
+			return err
+		}
+	}
+	c.captures[chatID]++
+	return nil
+}
+
+// releaseCapture releases a capture taken with acquireCapture, stopping it after the last one.
+func (c *TelegramCalls) releaseCapture(call *Assistant, chatID int64) {
+	c.captureMu.Lock()
+	defer c.captureMu.Unlock()
+	if c.captures[chatID] == 0 {
+		return
+	}
+	c.captures[chatID]--
+	if c.captures[chatID] > 0 {
+		return
+	}
+	delete(c.captures, chatID)
+	if call.binding.Calls()[chatID] == nil {
+		return
+	}
+	if err := call.binding.SetStreamSources(chatID, ntgcalls.PlaybackStream, ntgcalls.MediaDescription{}); err != nil {
+		logger.Warn("[capture] Failed to stop capturing the voice chat", "chatID", chatID, "error", err)
+	}
+}
+
+// onCallFrames receives the audio ntgcalls captures from the voice chats and hands it to the
+// recordings and bridges that need it.
+func (c *TelegramCalls) onCallFrames(chatID int64, mode ntgcalls.StreamMode, device ntgcalls.StreamDevice, frames []ntgcalls.Frame) {
+	if mode != ntgcalls.PlaybackStream || device != ntgcalls.SpeakerStream {
+		return
+	}
+	c.recordFrames(chatID, frames)
+	c.bridgeFrames(chatID, frames)
+}
+
+// addOverlaySource returns the mixer whose audio is mixed into a chat's outgoing audio with
+// source registered in it, creating the mixer if needed. Both happen under overlayMu, so
+// removeOverlaySource cannot drop the mixer before the source writes to it.
+func (c *TelegramCalls) addOverlaySource(chatID, source int64) *pcmMixer {
+	c.overlayMu.Lock()
+	defer c.overlayMu.Unlock()
+	m, ok := c.overlays[chatID]
+	if !ok {
+		m = newPCMMixer()
+		c.overlays[chatID] = m
+	}
+	m.write(source, nil)
+	return m
+}
+
+// removeOverlaySource drops a source from a chat's overlay, removing the overlay once it has
+// no sources left.
+func (c *TelegramCalls) removeOverlaySource(chatID, source int64) {
+	c.overlayMu.Lock()
+	defer c.overlayMu.Unlock()
+	m, ok := c.overlays[chatID]
+	if !ok {
+		return
+	}
+	m.remove(source)
+	m.mu.Lock()
+	empty := len(m.sources) == 0
+	m.mu.Unlock()
+	if empty {
+		delete(c.overlays, chatID)
+	}
+}
+
+// mixOverlay mixes the next frame of a chat's overlay into an outgoing audio frame in the
+// stream's format.
+func (c *TelegramCalls) mixOverlay(chatID int64, frame []byte, sampleRate, channels int) {
+	c.overlayMu.Lock()
+	m, ok := c.overlays[chatID]
+	c.overlayMu.Unlock()
+	if !ok {
+		return
+	}
+
+	pcm := make([]byte, mixFrameSize)
+	if !m.next(pcm) {
+		return
+	}
+	mixInto(frame, convertPCM(pcm, mixSampleRate, mixChannels, sampleRate, channels))
+}
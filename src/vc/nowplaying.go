@@ -0,0 +1,191 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/src/core"
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/utils"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+const (
+	// nowPlayingInterval is how often each now-playing card is refreshed.
+	nowPlayingInterval = 15 * time.Second
+	// nowPlayingEditGap spaces the edits of one refresh round, so many active chats do not
+	// hit the bot's global rate limit at once.
+	nowPlayingEditGap = 100 * time.Millisecond
+	progressBarWidth  = 12
+)
+
+// nowPlayingCard is the message showing a chat's playing track, kept up to date while it plays.
+type nowPlayingCard struct {
+	bot   *td.Client
+	msg   *td.Message
+	track *utils.CachedTrack
+	text  string
+}
+
+// ShowNowPlaying turns msg into the now-playing card of the chat's playing track and keeps
+// it updated. The previous card of the chat is collapsed to a single line.
+func (c *TelegramCalls) ShowNowPlaying(bot *td.Client, chatID int64, msg *td.Message) error {
+	track := cache.ChatCache.GetPlayingTrack(chatID)
+	if track == nil {
+		return nil
+	}
+
+	card := &nowPlayingCard{bot: bot, msg: msg, track: track}
+	card.text = c.nowPlayingText(chatID, track)
+	_, err := msg.EditText(bot, card.text, &td.EditTextMessageOpts{
+		ReplyMarkup:           c.nowPlayingButtons(chatID),
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.cardMu.Lock()
+	old := c.cards[chatID]
+	c.cards[chatID] = card
+	c.cardMu.Unlock()
+	if old != nil && old.msg.Id != msg.Id {
+		old.collapse()
+	}
+	return nil
+}
+
+// closeNowPlaying collapses a chat's now-playing card and stops updating it.
+func (c *TelegramCalls) closeNowPlaying(chatID int64) {
+	c.cardMu.Lock()
+	card := c.cards[chatID]
+	delete(c.cards, chatID)
+	c.cardMu.Unlock()
+	if card != nil {
+		card.collapse()
+	}
+}
+
+// collapse replaces the card with a single line naming the track and removes its buttons.
+func (card *nowPlayingCard) collapse() {
+	text := fmt.Sprintf("<b>Played:</b> <a href='%s'>%s</a>", html.EscapeString(card.track.URL), html.EscapeString(card.track.Name))
+	_, _ = card.msg.EditText(card.bot, text, &td.EditTextMessageOpts{ParseMode: "HTML", DisableWebPagePreview: true})
+}
+
+// startNowPlaying refreshes the now-playing cards every nowPlayingInterval.
+func (c *TelegramCalls) startNowPlaying() {
+	go func() {
+		ticker := time.NewTicker(nowPlayingInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			c.refreshNowPlaying()
+		}
+	}()
+}
+
+// refreshNowPlaying edits every card whose text changed. Cards whose track stopped playing are
+// collapsed, and cards that can no longer be edited are dropped.
+func (c *TelegramCalls) refreshNowPlaying() {
+	c.cardMu.Lock()
+	cards := make(map[int64]*nowPlayingCard, len(c.cards))
+	for chatID, card := range c.cards {
+		cards[chatID] = card
+	}
+	c.cardMu.Unlock()
+
+	for chatID, card := range cards {
+		if track := cache.ChatCache.GetPlayingTrack(chatID); track == nil || track.TrackID != card.track.TrackID {
+			c.dropCard(chatID, card)
+			card.collapse()
+			continue
+		}
+
+		text := c.nowPlayingText(chatID, card.track)
+		if text == card.text {
+			continue
+		}
+		_, err := card.msg.EditText(card.bot, text, &td.EditTextMessageOpts{
+			ReplyMarkup:           c.nowPlayingButtons(chatID),
+			ParseMode:             "HTML",
+			DisableWebPagePreview: true,
+		})
+		switch {
+		case err == nil:
+			card.text = text
+		case utils.GetFloodWait(err) > 0:
+			// The limit applies to the bot as a whole, so the round ends and the next one waits
+			// until it is lifted. Skipped ticks are dropped by the ticker.
+			time.Sleep(time.Duration(utils.GetFloodWait(err)) * time.Second)
+			return
+		case !strings.Contains(err.Error(), "not modified"):
+			logger.Debug("[nowplaying] Dropping a card that cannot be edited", "chatID", chatID, "error", err)
+			c.dropCard(chatID, card)
+		}
+		time.Sleep(nowPlayingEditGap)
+	}
+}
+
+// dropCard stops updating a card unless it was already replaced.
+func (c *TelegramCalls) dropCard(chatID int64, card *nowPlayingCard) {
+	c.cardMu.Lock()
+	defer c.cardMu.Unlock()
+	if c.cards[chatID] == card {
+		delete(c.cards, chatID)
+	}
+}
+
+// nowPlayingButtons returns the playback controls matching whether the chat is paused or muted.
+func (c *TelegramCalls) nowPlayingButtons(chatID int64) *td.ReplyMarkupInlineKeyboard {
+	return core.ControlButtons(c.PlaybackMode(chatID), db.Instance.GetVolume(chatID))
+}
+
+// nowPlayingText builds the now-playing card of a track: its progress, the next track and the
+// chat's loop, repeat and autoplay state.
+func (c *TelegramCalls) nowPlayingText(chatID int64, track *utils.CachedTrack) string {
+	status := "| Now playing"
+	switch c.PlaybackMode(chatID) {
+	case "pause":
+		status = "| Paused"
+	case "mute":
+		status = "| Muted"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<u><b>%s</b></u>\n\n<b>Title:</b> <a href='%s'>%s</a>\n", status, html.EscapeString(track.URL), html.EscapeString(track.Name))
+
+	played, _ := c.PlayedTime(chatID)
+	elapsed := int(played)
+	if track.IsLive || track.Duration <= 0 {
+		fmt.Fprintf(&b, "%s · %s elapsed\n", utils.TrackDuration(track), utils.SecToMin(elapsed))
+	} else {
+		elapsed = min(elapsed, track.Duration)
+		fmt.Fprintf(&b, "<code>%s %s %s</code>\n", utils.SecToMin(elapsed), utils.ProgressBar(elapsed, track.Duration, progressBarWidth), utils.SecToMin(track.Duration))
+	}
+	fmt.Fprintf(&b, "\n<b>Requested by:</b> %s\n", html.EscapeString(track.User))
+
+	if next := cache.ChatCache.GetUpcomingTrack(chatID); next != nil {
+		fmt.Fprintf(&b, "<b>Up next:</b> %s\n", html.EscapeString(next.Name))
+	} else if cache.ChatCache.GetAutoplay(chatID) {
+		b.WriteString("<b>Up next:</b> autoplay\n")
+	}
+
+	autoplay := "off"
+	if cache.ChatCache.GetAutoplay(chatID) {
+		autoplay = "on"
+	}
+	fmt.Fprintf(&b, "\n<b>Loop:</b> %d · <b>Repeat:</b> %s · <b>Autoplay:</b> %s",
+		cache.ChatCache.GetLoopCount(chatID), cache.ChatCache.GetRepeatMode(chatID), autoplay)
+	return b.String()
+}
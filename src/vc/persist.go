@@ -0,0 +1,200 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/config"
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/utils"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+const (
+	// queueSaveDelay is how long a changed queue waits before it is saved, so a burst of
+	// changes results in a single write.
+	queueSaveDelay = 2 * time.Second
+	// queueSaveInterval is how often the queues of active chats are saved, keeping their
+	// playback positions current.
+	queueSaveInterval  = 15 * time.Second
+	queueRestoreMaxAge = 6 * time.Hour
+)
+
+// RestoreQueues reloads the queues saved before the last shutdown, resumes playback
+// close to where it stopped and starts saving queue changes in the background. If the saved
+// queues cannot be loaded, nothing is saved either, so they are not overwritten.
+func (c *TelegramCalls) RestoreQueues(bot *td.Client) {
+	if !config.PersistQueues {
+		return
+	}
+
+	snaps, err := db.Instance.GetSavedQueues()
+	if err != nil {
+		logger.Warn("Failed to load saved queues, queue persistence is disabled", "error", err)
+		return
+	}
+
+	restored := 0
+	for _, snap := range snaps {
+		if len(snap.Queue) == 0 || time.Since(snap.UpdatedAt) > queueRestoreMaxAge {
+			_ = db.Instance.DeleteQueue(snap.ChatID)
+			continue
+		}
+
+		// A shared queue store may already hold this chat, served by another process.
+		if cache.ChatCache.IsActive(snap.ChatID) {
+			continue
+		}
+
+		cache.ChatCache.Restore(snap)
+		go c.resumeQueue(bot, snap.ChatID, snap.Position)
+		restored++
+	}
+
+	if restored > 0 {
+		logger.Info("Restored saved queues", "count", restored)
+	}
+
+	c.startQueuePersistence(context.Background())
+}
+
+// resumeQueue restarts the current track of a restored queue at the saved position.
+func (c *TelegramCalls) resumeQueue(bot *td.Client, chatID int64, position int) {
+	song := cache.ChatCache.GetPlayingTrack(chatID)
+	if song == nil {
+		return
+	}
+
+	if song.FilePath != "" && !urlRegex.MatchString(song.FilePath) {
+		if _, err := os.Stat(song.FilePath); err != nil {
+			song.FilePath = ""
+			cache.ChatCache.SetTrackFile(chatID, song.TrackID, "")
+		}
+	}
+
+	reply, err := bot.SendTextMessage(chatID, fmt.Sprintf("Resuming %s after a restart...", song.Name), nil)
+	if err != nil {
+		logger.Warn("Failed to send resume message", "chatID", chatID, "error", err)
+		cache.ChatCache.ClearChat(chatID)
+		return
+	}
+
+	if err = c.downloadAndPrepareSong(bot, chatID, song, reply); err != nil {
+		cache.ChatCache.RemoveCurrentSong(chatID)
+		_ = c.playCurrent(bot, chatID)
+		return
+	}
+
+	if !song.IsLive && song.Duration == 0 {
+		song.Duration = utils.GetMediaDuration(song.FilePath)
+		cache.ChatCache.SetTrackDuration(chatID, song.TrackID, song.Duration)
+	}
+
+	if position > 0 && position < song.Duration {
+		err = c.SeekStream(bot, chatID, song.FilePath, position, song.Duration, song.IsVideo)
+	} else {
+		err = c.PlayMedia(bot, chatID, song.FilePath, song.IsVideo)
+	}
+
+	if err != nil {
+		_, _ = reply.EditText(bot, err.Error(), &td.EditTextMessageOpts{ParseMode: "HTML", DisableWebPagePreview: true})
+		return
+	}
+
+	_ = c.ShowNowPlaying(bot, chatID, reply)
+}
+
+// startQueuePersistence saves a chat's queue shortly after it changes and periodically
+// writes the queues of active chats to the database.
+func (c *TelegramCalls) startQueuePersistence(ctx context.Context) {
+	c.persistMu.Lock()
+	c.persisting = true
+	c.persistMu.Unlock()
+
+	cache.ChatCache.OnChange(func(chatID int64) {
+		c.persistMu.Lock()
+		defer c.persistMu.Unlock()
+		c.dirtyQueues[chatID] = struct{}{}
+		if !c.savePending {
+			c.savePending = true
+			time.AfterFunc(queueSaveDelay, func() { c.saveQueues(false) })
+		}
+	})
+
+	go func() {
+		ticker := time.NewTicker(queueSaveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.saveQueues(true)
+			}
+		}
+	}()
+}
+
+// saveQueues writes the queue of every recently changed chat to the database, and of every
+// active chat as well when all is set. Chats whose queue is now empty have their saved queue removed.
+func (c *TelegramCalls) saveQueues(all bool) {
+	if !config.PersistQueues || db.Instance == nil {
+		return
+	}
+
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	c.persistMu.Lock()
+	if !c.persisting {
+		c.persistMu.Unlock()
+		return
+	}
+	chats := c.dirtyQueues
+	c.dirtyQueues = make(map[int64]struct{})
+	c.savePending = false
+	c.persistMu.Unlock()
+
+	if all {
+		for _, chatID := range cache.ChatCache.GetActiveChats() {
+			chats[chatID] = struct{}{}
+		}
+	}
+
+	for chatID := range chats {
+		snap := cache.ChatCache.Snapshot(chatID)
+		if snap == nil {
+			if err := db.Instance.DeleteQueue(chatID); err != nil {
+				logger.Warn("Failed to delete saved queue", "chatID", chatID, "error", err)
+			}
+			continue
+		}
+
+		if !isIngest(cache.ChatCache.GetPlayingTrack(chatID)) {
+			snap.Position = c.playedSeconds(chatID)
+		}
+		if err := db.Instance.SaveQueue(snap); err != nil {
+			logger.Warn("Failed to save queue", "chatID", chatID, "error", err)
+		}
+	}
+}
+
+// playedSeconds returns how far into the current track a chat is, or 0 if unknown.
+func (c *TelegramCalls) playedSeconds(chatID int64) int {
+	played, err := c.PlayedTime(chatID)
+	if err != nil {
+		return 0
+	}
+	return int(played)
+}
@@ -52,14 +52,19 @@ func fatalMessage(err error) error {
 	return err
 }
 
-// PlayMedia plays media in a voice chat with automatic assistant rotation on certain errors.
-func (c *TelegramCalls) PlayMedia(bot *td.Client, chatID int64, filePath string, video bool, ffmpegParameters string) error {
+// PlayMedia plays media in a voice chat from the start, with automatic assistant rotation on certain errors.
+func (c *TelegramCalls) PlayMedia(bot *td.Client, chatID int64, filePath string, video bool) error {
+	return c.playRange(bot, chatID, filePath, video, seekRange{})
+}
+
+// playRange plays part of a media file in a voice chat like PlayMedia.
+func (c *TelegramCalls) playRange(bot *td.Client, chatID int64, filePath string, video bool, seek seekRange) error {
 	call, index, err := c.GetGroupAssistant(chatID)
 	if err != nil {
 		return err
 	}
 
-	err = c.playMedia(bot, chatID, filePath, video, ffmpegParameters, call, index)
+	err = c.playMedia(bot, chatID, filePath, video, seek, call, index)
 	if err == nil {
 		_ = db.Instance.SetAssistant(chatID, index)
 		return nil
@@ -74,7 +79,7 @@ func (c *TelegramCalls) PlayMedia(bot *td.Client, chatID int64, filePath string,
 		return fmt.Errorf("playback failed: %w", err)
 
 	case errRetryOnce:
-		err = c.playMedia(bot, chatID, filePath, video, ffmpegParameters, call, index)
+		err = c.playMedia(bot, chatID, filePath, video, seek, call, index)
 		if err == nil {
 			_ = db.Instance.SetAssistant(chatID, index)
 			return nil
@@ -88,7 +93,7 @@ func (c *TelegramCalls) PlayMedia(bot *td.Client, chatID int64, filePath string,
 		c.evictAssistant(chatID, index, err)
 	}
 
-	return c.rotateAndPlay(bot, chatID, filePath, video, ffmpegParameters, map[int]bool{index: true}, err)
+	return c.rotateAndPlay(bot, chatID, filePath, video, seek, map[int]bool{index: true}, err)
 }
 
 // evictAssistant cleans up state for an assistant that can no longer serve a chat.
@@ -99,7 +104,7 @@ func (c *TelegramCalls) evictAssistant(chatID int64, index int, err error) {
 	}
 }
 
-func (c *TelegramCalls) playMedia(bot *td.Client, chatID int64, filePath string, video bool, ffmpegParameters string, call *Assistant, index int) error {
+func (c *TelegramCalls) playMedia(bot *td.Client, chatID int64, filePath string, video bool, seek seekRange, call *Assistant, index int) error {
 	if chatID > 0 {
 		return errors.New("private calls are not supported for media playback")
 	}
@@ -109,13 +114,20 @@ func (c *TelegramCalls) playMedia(bot *td.Client, chatID int64, filePath string,
 		return err
 	}
 
-	logger.Debug("Playing media in chat", "id", chatID, "path", filePath, "index", index)
+	logger.Debug("Playing media in chat", "id", chatID, "index", index)
 
-	mediaDesc := getMediaDescription(filePath, video, chatID, ffmpegParameters)
+	mediaDesc, source := getMediaDescription(filePath, video, chatID, seek)
+	c.stopStream(chatID)
 	if err := call.Play(context.Background(), chatID, mediaDesc); err != nil {
 		cache.ChatCache.ClearChat(chatID)
 		return err
 	}
+	stream := c.startStream(chatID, call, source)
+	if source.live && isRadioStream(cache.ChatCache.GetPlayingTrack(chatID), filePath) {
+		go watchLiveMetadata(bot, stream, filePath)
+	}
+	c.setStreamState(chatID, seek)
+	go c.prefetchNext(bot, chatID)
 
 	if db.Instance.GetLoggerStatus() {
 		go sendLogger(bot, chatID, cache.ChatCache.GetPlayingTrack(chatID))
@@ -125,7 +137,7 @@ func (c *TelegramCalls) playMedia(bot *td.Client, chatID int64, filePath string,
 }
 
 // rotateAndPlay iterates over all remaining assistants until one succeeds or all are exhausted.
-func (c *TelegramCalls) rotateAndPlay(bot *td.Client, chatID int64, filePath string, video bool, ffmpegParameters string, tried map[int]bool, lastErr error) error {
+func (c *TelegramCalls) rotateAndPlay(bot *td.Client, chatID int64, filePath string, video bool, seek seekRange, tried map[int]bool, lastErr error) error {
 	for {
 		call, nextIndex, err := c.nextUntried(tried)
 		if err != nil {
@@ -134,7 +146,7 @@ func (c *TelegramCalls) rotateAndPlay(bot *td.Client, chatID int64, filePath str
 		}
 		tried[nextIndex] = true
 
-		err = c.playMedia(bot, chatID, filePath, video, ffmpegParameters, call, nextIndex)
+		err = c.playMedia(bot, chatID, filePath, video, seek, call, nextIndex)
 		if err == nil {
 			_ = db.Instance.SetAssistant(chatID, nextIndex)
 			return nil
@@ -143,7 +155,7 @@ func (c *TelegramCalls) rotateAndPlay(bot *td.Client, chatID int64, filePath str
 
 		switch classifyError(err) {
 		case errRetryOnce:
-			err = c.playMedia(bot, chatID, filePath, video, ffmpegParameters, call, nextIndex)
+			err = c.playMedia(bot, chatID, filePath, video, seek, call, nextIndex)
 			if err == nil {
 				_ = db.Instance.SetAssistant(chatID, nextIndex)
 				return nil
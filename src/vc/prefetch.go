@@ -0,0 +1,145 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/src/core/cache"
+	"ashokshau/tgmusic/src/core/db"
+	"ashokshau/tgmusic/src/core/dl"
+	"ashokshau/tgmusic/src/utils"
+	"context"
+	"errors"
+	"time"
+
+	td "github.com/AshokShau/gotdbot"
+)
+
+// prefetchTask is a background download of a chat's upcoming track.
+type prefetchTask struct {
+	trackID string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	// done is closed once path and err are set.
+	done chan struct{}
+	path string
+	err  error
+}
+
+// startPrefetcher keeps the upcoming track of every chat downloading in the background,
+// so that moving to the next track does not wait for a download.
+func (c *TelegramCalls) startPrefetcher(bot *td.Client) {
+	cache.ChatCache.OnChange(func(chatID int64) {
+		go c.prefetchNext(bot, chatID)
+	})
+}
+
+// prefetchNext starts downloading a chat's upcoming track if it has no file yet. Prefetches
+// of tracks that are neither upcoming nor playing any more are cancelled.
+func (c *TelegramCalls) prefetchNext(bot *td.Client, chatID int64) {
+	c.prefetchMu.Lock()
+	defer c.prefetchMu.Unlock()
+
+	next := cache.ChatCache.GetUpcomingTrack(chatID)
+	current := cache.ChatCache.GetPlayingTrack(chatID)
+
+	tasks := c.prefetches[chatID]
+	for trackID, task := range tasks {
+		// A track that just moved to the head of the queue is kept, since playSong waits for it.
+		if (next != nil && trackID == next.TrackID) || (current != nil && trackID == current.TrackID) {
+			continue
+		}
+		task.cancel()
+		delete(tasks, trackID)
+	}
+
+	if next == nil || next.FilePath != "" || isIngest(next) {
+		if len(tasks) == 0 {
+			delete(c.prefetches, chatID)
+		}
+		return
+	}
+	if _, ok := tasks[next.TrackID]; ok {
+		return
+	}
+
+	if tasks == nil {
+		tasks = make(map[string]*prefetchTask)
+		c.prefetches[chatID] = tasks
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	task := &prefetchTask{trackID: next.TrackID, ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	tasks[next.TrackID] = task
+	song := *next
+	if song.Quality == "" {
+		song.Quality = db.Instance.GetQuality(chatID)
+	}
+	go c.runPrefetch(bot, chatID, task, &song)
+}
+
+// prefetchDelay is how long a prefetch waits before downloading, so a queue that is being
+// rearranged does not start a download for every track that is briefly up next.
+const prefetchDelay = 2 * time.Second
+
+// runPrefetch downloads a track for a prefetch task and records the file in the queue.
+// Cancelling the task stops the download; a Telegram download cannot be stopped, so only its
+// result is discarded.
+func (c *TelegramCalls) runPrefetch(bot *td.Client, chatID int64, task *prefetchTask, song *utils.CachedTrack) {
+	select {
+	case <-time.After(prefetchDelay):
+	case <-task.ctx.Done():
+		task.err = task.ctx.Err()
+		close(task.done)
+		return
+	}
+
+	var path string
+	var err error
+	result := make(chan struct{})
+	go func() {
+		path, err = dl.DownloadCachedTrack(task.ctx, song, bot)
+		close(result)
+	}()
+
+	select {
+	case <-result:
+		task.path, task.err = path, err
+		if task.err == nil && task.path == "" {
+			task.err = errors.New("download returned no file")
+		}
+	case <-task.ctx.Done():
+		task.err = task.ctx.Err()
+	}
+	close(task.done)
+
+	if task.ctx.Err() != nil {
+		return
+	}
+	if task.err != nil {
+		logger.Warn("[prefetch] Failed to download the upcoming track", "chatID", chatID, "trackID", task.trackID, "error", task.err)
+		return
+	}
+	cache.ChatCache.SetTrackFile(chatID, task.trackID, task.path)
+}
+
+// awaitPrefetch waits for a running prefetch of a track and returns the downloaded file.
+// It returns "" when the track is not being prefetched or the prefetch failed.
+func (c *TelegramCalls) awaitPrefetch(chatID int64, trackID string) string {
+	c.prefetchMu.Lock()
+	task, ok := c.prefetches[chatID][trackID]
+	c.prefetchMu.Unlock()
+	if !ok {
+		return ""
+	}
+
+	<-task.done
+	if task.err != nil {
+		return ""
+	}
+	return task.path
+}
@@ -0,0 +1,303 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/config"
+	"ashokshau/tgmusic/src/utils"
+	"ashokshau/tgmusic/src/vc/ntgcalls"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	td "github.com/AshokShau/gotdbot"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// Recording modes.
+const (
+	// RecordCall records what participants say mixed with the bot's own output.
+	RecordCall = "call"
+	// RecordBot records only what the bot plays.
+	RecordBot = "bot"
+)
+
+// ownSource is the mixer source of the bot's own output; participants use their SSRC.
+const ownSource int64 = -1
+
+// recording encodes a chat's voice chat audio to an OGG/Opus file. Audio from participants
+// and from the bot is buffered per source and mixed every audioFrameDuration, so the file
+// keeps real time even while nobody speaks.
+type recording struct {
+	bot     *td.Client
+	call    *Assistant
+	chatID  int64
+	mode    string
+	path    string
+	started time.Time
+	// notice is the message announcing the recording; the file is uploaded as a reply to it.
+	notice *td.Message
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+	ctx    context.Context
+	cancel context.CancelFunc
+	// mixed is closed once mix has stopped writing to ffmpeg.
+	mixed chan struct{}
+
+	// audio buffers the participants' audio and the bot's output until it is mixed.
+	audio *pcmMixer
+
+	mu     sync.Mutex
+	frames int
+	reason string
+}
+
+// StartRecording starts recording a chat's voice chat in the given mode. notice is the
+// message that tells participants about the recording.
+func (c *TelegramCalls) StartRecording(bot *td.Client, chatID int64, mode string, notice *td.Message) error {
+	call, _, err := c.GetGroupAssistant(chatID)
+	if err != nil {
+		return err
+	}
+	if call.binding.Calls()[chatID] == nil {
+		return errors.New("the bot is not in the voice chat")
+	}
+
+	c.recordMu.Lock()
+	defer c.recordMu.Unlock()
+	if _, ok := c.recordings[chatID]; ok {
+		return errors.New("the voice chat is already being recorded")
+	}
+	if err = c.checkRecordSpace(); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &recording{
+		bot:     bot,
+		call:    call,
+		chatID:  chatID,
+		mode:    mode,
+		path:    filepath.Join(config.DownloadsDir, fmt.Sprintf("record_%d_%s.ogg", -chatID, time.Now().Format("20060102_150405"))),
+		started: time.Now(),
+		notice:  notice,
+		ctx:     ctx,
+		cancel:  cancel,
+		mixed:   make(chan struct{}),
+		audio:   newPCMMixer(),
+	}
+
+	// ffmpeg enforces the limits itself; the recording stops when it exits.
+	r.cmd = exec.Command("ffmpeg", "-hide_banner", "-v", "error",
+		"-f", "s16le", "-ar", strconv.Itoa(mixSampleRate), "-ac", strconv.Itoa(mixChannels), "-i", "pipe:0",
+		"-c:a", "libopus", "-b:a", "64k",
+		"-t", strconv.FormatInt(config.RecordMaxDuration, 10),
+		"-fs", strconv.FormatInt(config.RecordMaxSize, 10),
+		"-y", "file:"+r.path,
+	)
+	r.cmd.Stderr = &r.stderr
+	if r.stdin, err = r.cmd.StdinPipe(); err != nil {
+		cancel()
+		return err
+	}
+	if err = r.cmd.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	if mode == RecordCall {
+		if err = c.acquireCapture(call, chatID); err != nil {
+			cancel()
+			_ = r.stdin.Close()
+			_ = r.cmd.Wait()
+			_ = os.Remove(r.path)
+			return fmt.Errorf("failed to capture the voice chat: %w", err)
+		}
+	}
+
+	c.recordings[chatID] = r
+	go r.mix()
+	go c.finishRecording(r)
+	return nil
+}
+
+// checkRecordSpace reports an error unless another recording of up to RECORD_MAX_SIZE fits
+// both in the RECORD_TOTAL_SIZE budget shared by all recordings and on the disk. Each running
+// recording is counted at its maximum size. Caller must hold recordMu.
+func (c *TelegramCalls) checkRecordSpace() error {
+	if int64(len(c.recordings)+1)*config.RecordMaxSize > config.RecordTotalSize {
+		return errors.New("too many voice chats are being recorded, try again later")
+	}
+	usage, err := disk.Usage(config.DownloadsDir)
+	if err != nil {
+		return fmt.Errorf("failed to check the free disk space: %w", err)
+	}
+	if int64(usage.Free) < int64(len(c.recordings)+1)*config.RecordMaxSize {
+		return errors.New("there is not enough free disk space to record")
+	}
+	return nil
+}
+
+// StopRecording stops recording a chat's voice chat. The file is uploaded in the background.
+func (c *TelegramCalls) StopRecording(chatID int64, reason string) error {
+	c.recordMu.Lock()
+	r, ok := c.recordings[chatID]
+	c.recordMu.Unlock()
+	if !ok {
+		return errors.New("the voice chat is not being recorded")
+	}
+	r.stop(reason)
+	return nil
+}
+
+// IsRecording reports whether a chat's voice chat is being recorded.
+func (c *TelegramCalls) IsRecording(chatID int64) bool {
+	c.recordMu.Lock()
+	defer c.recordMu.Unlock()
+	_, ok := c.recordings[chatID]
+	return ok
+}
+
+func (c *TelegramCalls) getRecording(chatID int64) *recording {
+	c.recordMu.Lock()
+	defer c.recordMu.Unlock()
+	return c.recordings[chatID]
+}
+
+// recordFrames receives the audio of the voice chat's participants while it is recorded.
+func (c *TelegramCalls) recordFrames(chatID int64, frames []ntgcalls.Frame) {
+	r := c.getRecording(chatID)
+	if r == nil || r.mode != RecordCall {
+		return
+	}
+	for _, frame := range frames {
+		r.audio.write(int64(frame.Ssrc), frame.Data)
+	}
+}
+
+// recordOutput receives a frame of the bot's own output while the chat is recorded.
+func (c *TelegramCalls) recordOutput(chatID int64, frame []byte, sampleRate, channels int) {
+	r := c.getRecording(chatID)
+	if r == nil {
+		return
+	}
+	r.audio.write(ownSource, convertPCM(frame, sampleRate, channels, mixSampleRate, mixChannels))
+}
+
+// finishRecording waits for a recording to end, then uploads and deletes the file.
+func (c *TelegramCalls) finishRecording(r *recording) {
+	<-r.mixed
+	waitErr := r.cmd.Wait()
+
+	c.recordMu.Lock()
+	delete(c.recordings, r.chatID)
+	c.recordMu.Unlock()
+
+	if r.mode == RecordCall {
+		c.releaseCapture(r.call, r.chatID)
+	}
+	defer func() { _ = os.Remove(r.path) }()
+
+	r.mu.Lock()
+	length := r.frames * int(audioFrameDuration/time.Millisecond) / 1000
+	reason := r.reason
+	r.mu.Unlock()
+
+	if info, err := os.Stat(r.path); err != nil || info.Size() == 0 {
+		logger.Warn("[record] The recording produced no file", "chatID", r.chatID, "error", waitErr, "stderr", strings.TrimSpace(r.stderr.String()))
+		return
+	}
+
+	caption := fmt.Sprintf("🎙 Voice chat recording\nLength: %s\nStarted: %s\nStopped: %s",
+		utils.SecToMin(length), r.started.Format("2006-01-02 15:04 MST"), reason)
+	target := r.notice
+	if config.RecordUpload == "logger" && config.LoggerId != 0 {
+		msg, err := r.bot.SendTextMessage(config.LoggerId, fmt.Sprintf("Recording of the voice chat in %d", r.chatID), nil)
+		if err == nil {
+			target = msg
+		}
+		// The file goes to the log, so participants are told here that the recording is over.
+		if r.notice != nil {
+			_, _ = r.notice.ReplyText(r.bot, "⏹ The recording stopped: "+reason, nil)
+		}
+	}
+	if target == nil {
+		return
+	}
+	if _, err := target.ReplyDocument(r.bot, td.InputFileLocal{Path: r.path}, &td.SendDocumentOpts{Caption: caption}); err != nil {
+		logger.Warn("[record] Failed to upload the recording", "chatID", r.chatID, "error", err)
+	}
+}
+
+// stop ends the recording, recording why. Only the first reason is kept.
+func (r *recording) stop(reason string) {
+	r.mu.Lock()
+	if r.reason == "" {
+		r.reason = reason
+	}
+	r.mu.Unlock()
+	r.cancel()
+}
+
+// mix writes one mixed frame to ffmpeg every audioFrameDuration until the recording stops,
+// ffmpeg reaches a limit and exits, or the assistant leaves the voice chat.
+func (r *recording) mix() {
+	defer close(r.mixed)
+	defer func() { _ = r.stdin.Close() }()
+	ticker := time.NewTicker(audioFrameDuration)
+	defer ticker.Stop()
+
+	out := make([]byte, mixFrameSize)
+	for tick := 1; ; tick++ {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if tick%int(time.Second/audioFrameDuration) == 0 && r.call.binding.Calls()[r.chatID] == nil {
+			r.stop("the voice chat ended")
+			return
+		}
+
+		r.audio.next(out)
+		if _, err := r.stdin.Write(out); err != nil {
+			r.stop(r.limitReason())
+			return
+		}
+
+		r.mu.Lock()
+		r.frames++
+		r.mu.Unlock()
+	}
+}
+
+// limitReason explains why ffmpeg stopped accepting audio.
+func (r *recording) limitReason() string {
+	r.mu.Lock()
+	length := int64(r.frames) * int64(audioFrameDuration/time.Millisecond) / 1000
+	r.mu.Unlock()
+	if length >= config.RecordMaxDuration {
+		return "maximum length reached"
+	}
+	if info, err := os.Stat(r.path); err == nil && info.Size() >= config.RecordMaxSize {
+		return "maximum size reached"
+	}
+	return "encoding failed"
+}
@@ -0,0 +1,138 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/config"
+	"ashokshau/tgmusic/src/core/cache"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxClipsPlaying is how many soundboard clips may play over a chat's stream at once.
+	maxClipsPlaying = 3
+	// clipLeadFrames is how far ahead of the stream a clip's audio is buffered, so a late
+	// tick does not leave a gap in the clip.
+	clipLeadFrames = 5
+)
+
+// clipSources numbers the overlay sources of playing clips. Clip sources are positive, so
+// they never collide with the group chat IDs bridges use as sources.
+var clipSources atomic.Int64
+
+// clipAudio caches decoded clips by file path, since the same few clips are played often.
+var clipAudio = cache.NewCache[[]byte](30 * time.Minute)
+
+// PlayClip mixes a soundboard clip over the stream playing in a chat without interrupting it.
+// path is the clip's local file.
+func (c *TelegramCalls) PlayClip(chatID int64, path string) error {
+	s, ok := c.getStream(chatID)
+	if !ok {
+		return errors.New("nothing is playing")
+	}
+	if s.isPaused() {
+		return errors.New("playback is paused")
+	}
+
+	c.clipMu.Lock()
+	if c.clips[chatID] >= maxClipsPlaying {
+		c.clipMu.Unlock()
+		return fmt.Errorf("%d clips are already playing", maxClipsPlaying)
+	}
+	c.clips[chatID]++
+	c.clipMu.Unlock()
+
+	pcm, err := decodeClip(path)
+	if err != nil {
+		c.endClip(chatID)
+		return err
+	}
+
+	go c.playClip(chatID, s, pcm)
+	return nil
+}
+
+// playClip feeds a decoded clip into a chat's overlay in real time until it ends or the
+// stream it was played over stops.
+func (c *TelegramCalls) playClip(chatID int64, s *externalStream, pcm []byte) {
+	source := clipSources.Add(1)
+	overlay := c.addOverlaySource(chatID, source)
+	defer c.endClip(chatID)
+	defer c.removeOverlaySource(chatID, source)
+
+	lead := min(len(pcm), clipLeadFrames*mixFrameSize)
+	overlay.write(source, pcm[:lead])
+	pcm = pcm[lead:]
+
+	ticker := time.NewTicker(audioFrameDuration)
+	defer ticker.Stop()
+	for len(pcm) > 0 {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n := min(len(pcm), mixFrameSize)
+		overlay.write(source, pcm[:n])
+		pcm = pcm[n:]
+	}
+
+	// Let the buffered lead play out before the source is removed.
+	select {
+	case <-s.ctx.Done():
+	case <-time.After(clipLeadFrames * audioFrameDuration):
+	}
+}
+
+func (c *TelegramCalls) endClip(chatID int64) {
+	c.clipMu.Lock()
+	defer c.clipMu.Unlock()
+	if c.clips[chatID]--; c.clips[chatID] <= 0 {
+		delete(c.clips, chatID)
+	}
+}
+
+// decodeClip decodes a clip to PCM in the mix format, cut to SOUNDBOARD_MAX_LENGTH. The
+// last partial frame is padded with silence.
+func decodeClip(path string) ([]byte, error) {
+	if pcm, ok := clipAudio.Get(path); ok {
+		return pcm, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-hide_banner", "-v", "error",
+		"-i", "file:"+path, "-vn",
+		"-t", strconv.FormatInt(config.SoundboardMaxLength, 10),
+		"-f", "s16le", "-ar", strconv.Itoa(mixSampleRate), "-ac", strconv.Itoa(mixChannels), "pipe:1",
+	)
+	cmd.Stderr = &stderr
+	pcm, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the clip: %s", strings.TrimSpace(stderr.String()))
+	}
+	if len(pcm) == 0 {
+		return nil, errors.New("the clip has no audio")
+	}
+	if rest := len(pcm) % mixFrameSize; rest != 0 {
+		pcm = append(pcm, make([]byte, mixFrameSize-rest)...)
+	}
+
+	clipAudio.Set(path, pcm)
+	return pcm, nil
+}
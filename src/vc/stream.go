@@ -0,0 +1,469 @@
+/*
+ * TgMusicBot - Telegram Music Bot
+ *  Copyright (c) 2025-2026 Ashok Shau
+ *
+ *  Licensed under GNU GPL v3
+ *  See https://github.com/AshokShau/TgMusicBot
+ */
+
+package vc
+
+import (
+	"ashokshau/tgmusic/src/vc/ntgcalls"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// audioFrameDuration is the length of the PCM frames sent to ntgcalls.
+	audioFrameDuration = 10 * time.Millisecond
+	// maxDecoderRestarts is how many times a crashed ffmpeg process is restarted for one stream.
+	maxDecoderRestarts = 3
+	// maxFrameLag is how far behind the clock a stream may fall before it stops catching up.
+	maxFrameLag = time.Second
+)
+
+// errSendFrame marks a failure to hand a frame to ntgcalls, which means the call is gone.
+var errSendFrame = errors.New("failed to send frame")
+
+// streamStats are decoding measurements for the stream playing in a chat. They are logged
+// when the stream ends.
+type streamStats struct {
+	AudioFrames uint64
+	VideoFrames uint64
+	// LateFrames counts frames ffmpeg delivered after they were due.
+	LateFrames uint64
+	// Restarts counts how many times a crashed ffmpeg process was restarted.
+	Restarts int
+}
+
+// externalStream feeds a chat's call with frames decoded by Go-managed ffmpeg processes.
+// Audio is the master clock: when it ends the chat's stream end callbacks run.
+type externalStream struct {
+	chatID int64
+	call   *Assistant
+	source *mediaSource
+	ctx    context.Context
+	cancel context.CancelFunc
+	// mixIn receives every audio frame before it is sent and may mix other audio, such as a
+	// bridged voice chat, into it.
+	mixIn func(frame []byte)
+	// onAudio receives every audio frame sent to the call.
+	onAudio func(frame []byte)
+
+	mu      sync.Mutex
+	paused  bool
+	muted   bool
+	resumed chan struct{}
+	stats   streamStats
+	// restartPending is set when a setting changed while the stream was paused or muted, so
+	// the stream restarts with it once playback is heard again.
+	restartPending bool
+}
+
+// startStream starts feeding a chat's call from source, replacing any previous stream.
+func (c *TelegramCalls) startStream(chatID int64, call *Assistant, source *mediaSource) *externalStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &externalStream{chatID: chatID, call: call, source: source, ctx: ctx, cancel: cancel}
+	s.mixIn = func(frame []byte) {
+		c.bridgeOutput(chatID, frame, source.sampleRate, source.channelCount)
+		c.mixOverlay(chatID, frame, source.sampleRate, source.channelCount)
+	}
+	s.onAudio = func(frame []byte) {
+		c.recordOutput(chatID, frame, source.sampleRate, source.channelCount)
+	}
+
+	c.externalMu.Lock()
+	if old, ok := c.externals[chatID]; ok {
+		old.cancel()
+	}
+	c.externals[chatID] = s
+	c.externalMu.Unlock()
+
+	go func() {
+		s.run()
+		c.externalMu.Lock()
+		if c.externals[chatID] == s {
+			delete(c.externals, chatID)
+		}
+		c.externalMu.Unlock()
+	}()
+	return s
+}
+
+// stopStream stops the ffmpeg processes feeding a chat's call without running the stream
+// end callbacks.
+func (c *TelegramCalls) stopStream(chatID int64) {
+	c.externalMu.Lock()
+	defer c.externalMu.Unlock()
+	if s, ok := c.externals[chatID]; ok {
+		s.cancel()
+		delete(c.externals, chatID)
+	}
+}
+
+// getStream returns the stream feeding a chat's call, if any.
+func (c *TelegramCalls) getStream(chatID int64) (*externalStream, bool) {
+	c.externalMu.Lock()
+	defer c.externalMu.Unlock()
+	s, ok := c.externals[chatID]
+	return s, ok
+}
+
+// run decodes the stream until the audio ends, the stream is stopped or ffmpeg keeps failing.
+func (s *externalStream) run() {
+	if s.source.listen {
+		s.relay()
+		return
+	}
+
+	if s.source.video {
+		go func() {
+			if err := s.supervise(s.source.videoDevice); err != nil && s.ctx.Err() == nil {
+				logger.Warn("[stream] Video decoding stopped", "chatID", s.chatID, "error", err)
+			}
+		}()
+	}
+
+	err := s.supervise(ntgcalls.MicrophoneStream)
+	stats := s.snapshot()
+	logger.Debug("[stream] Stream finished", "chatID", s.chatID, "stopped", s.ctx.Err() != nil, "audioFrames", stats.AudioFrames,
+		"videoFrames", stats.VideoFrames, "lateFrames", stats.LateFrames, "restarts", stats.Restarts)
+	if s.ctx.Err() != nil {
+		return
+	}
+	s.cancel()
+
+	if errors.Is(err, errSendFrame) {
+		logger.Warn("[stream] Stopped feeding the call", "chatID", s.chatID, "error", err)
+		return
+	}
+	if err != nil {
+		logger.Warn("[stream] Audio decoding failed", "chatID", s.chatID, "error", err)
+	}
+	s.call.emitStreamEnd(s.chatID, ntgcalls.AudioStream, ntgcalls.MicrophoneStream)
+}
+
+// supervise runs the ffmpeg process for one device, restarting it at the current position
+// when it crashes. It returns nil once the input has been fully decoded.
+func (s *externalStream) supervise(device ntgcalls.StreamDevice) error {
+	for {
+		args := s.source.audioArgs(s.position())
+		if device != ntgcalls.MicrophoneStream {
+			args = s.source.videoArgs(s.position())
+		}
+
+		err := s.pump(device, args)
+		if err == nil || s.ctx.Err() != nil || errors.Is(err, errSendFrame) {
+			return err
+		}
+
+		s.mu.Lock()
+		s.stats.Restarts++
+		restarts := s.stats.Restarts
+		s.mu.Unlock()
+		if restarts > maxDecoderRestarts {
+			return err
+		}
+
+		logger.Warn("[stream] Restarting ffmpeg", "chatID", s.chatID, "device", device, "attempt", restarts, "error", err)
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-time.After(time.Duration(restarts) * time.Second):
+		}
+	}
+}
+
+// relay keeps an ingest listener running until the stream is stopped, waiting for the next
+// push whenever a streamer disconnects. The stream end callbacks only run when the listener
+// keeps failing, for example because its port is taken.
+func (s *externalStream) relay() {
+	failures := 0
+	for {
+		err := s.listen()
+		if s.ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errSendFrame) {
+			s.cancel()
+			logger.Warn("[stream] Stopped feeding the call", "chatID", s.chatID, "error", err)
+			return
+		}
+		if err == nil {
+			failures = 0
+			logger.Info("[stream] Streamer disconnected, waiting for the next push", "chatID", s.chatID)
+			continue
+		}
+
+		failures++
+		s.mu.Lock()
+		s.stats.Restarts++
+		s.mu.Unlock()
+		if failures > maxDecoderRestarts {
+			s.cancel()
+			logger.Warn("[stream] Ingest listener failed", "chatID", s.chatID, "error", err)
+			s.call.emitStreamEnd(s.chatID, ntgcalls.AudioStream, ntgcalls.MicrophoneStream)
+			return
+		}
+
+		logger.Warn("[stream] Restarting the ingest listener", "chatID", s.chatID, "attempt", failures, "error", err)
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(time.Duration(failures) * time.Second):
+		}
+	}
+}
+
+// listen runs one ffmpeg listener until the streamer disconnects. Audio comes from its stdout
+// and video from a second pipe, since a single process has to decode both.
+func (s *externalStream) listen() error {
+	cmd := exec.CommandContext(s.ctx, "ffmpeg", s.source.listenArgs()...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	var video *os.File
+	if s.source.video {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		// The write end becomes ffmpeg's file descriptor 3, which listenArgs writes the video to.
+		cmd.ExtraFiles = []*os.File{w}
+		video = r
+	}
+
+	err = cmd.Start()
+	// Only ffmpeg may hold the write end, so the video reader sees the end of the push.
+	for _, f := range cmd.ExtraFiles {
+		_ = f.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	videoErr := make(chan error, 1)
+	if video != nil {
+		go func() {
+			err := s.sendFrames(s.source.videoDevice, video)
+			if err != nil {
+				// Nobody reads the video any more, which would block ffmpeg.
+				_ = cmd.Process.Kill()
+			}
+			videoErr <- err
+		}()
+	} else {
+		videoErr <- nil
+	}
+
+	err = s.finish(cmd, &stderr, s.sendFrames(ntgcalls.MicrophoneStream, stdout))
+	if vErr := <-videoErr; vErr != nil && s.ctx.Err() == nil {
+		return vErr
+	}
+	return err
+}
+
+// pump runs one ffmpeg process and sends its output to the call frame by frame, paced in
+// real time. It returns nil when ffmpeg exited after reaching the end of the input.
+func (s *externalStream) pump(device ntgcalls.StreamDevice, args []string) error {
+	cmd := exec.CommandContext(s.ctx, "ffmpeg", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	sendErr := s.sendFrames(device, stdout)
+	return s.finish(cmd, &stderr, sendErr)
+}
+
+// finish waits for an ffmpeg process, killing it first if its frames could not be sent, and
+// returns why it stopped.
+func (s *externalStream) finish(cmd *exec.Cmd, stderr *bytes.Buffer, sendErr error) error {
+	if sendErr != nil {
+		_ = cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+	switch {
+	case s.ctx.Err() != nil:
+		return s.ctx.Err()
+	case sendErr != nil:
+		return sendErr
+	case waitErr != nil:
+		return fmt.Errorf("ffmpeg exited: %w: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// sendFrames reads the frames of one device from r and sends them to the call, paced in real
+// time, until r ends or the stream stops. It only returns an error when a frame could not be sent.
+func (s *externalStream) sendFrames(device ntgcalls.StreamDevice, r io.Reader) error {
+	frameSize := s.source.sampleRate * s.source.channelCount * 2 * int(audioFrameDuration/time.Millisecond) / 1000
+	interval := audioFrameDuration
+	frameData := ntgcalls.FrameData{}
+	if device != ntgcalls.MicrophoneStream {
+		frameSize = s.source.width * s.source.height * 3 / 2
+		interval = time.Second / time.Duration(s.source.fps)
+		frameData.Width, frameData.Height = uint16(s.source.width), uint16(s.source.height)
+	}
+
+	br := bufio.NewReaderSize(r, frameSize*4)
+	frame := make([]byte, frameSize)
+	next := time.Now()
+	for {
+		if s.waitResumed() {
+			next = time.Now()
+		}
+		if _, err := io.ReadFull(br, frame); err != nil {
+			return nil
+		}
+
+		now := time.Now()
+		if lag := now.Sub(next); lag > interval {
+			s.mu.Lock()
+			s.stats.LateFrames++
+			s.mu.Unlock()
+			if lag > maxFrameLag {
+				next = now
+			}
+		} else if lag < 0 {
+			select {
+			case <-s.ctx.Done():
+			case <-time.After(-lag):
+			}
+		}
+		if s.ctx.Err() != nil {
+			return nil
+		}
+
+		if device == ntgcalls.MicrophoneStream && s.mixIn != nil {
+			s.mixIn(frame)
+		}
+		frameData.AbsoluteCaptureTimestampMs = time.Now().UnixMilli()
+		if err := s.call.binding.SendExternalFrame(s.chatID, device, frame, frameData); err != nil {
+			return fmt.Errorf("%w: %v", errSendFrame, err)
+		}
+		if device == ntgcalls.MicrophoneStream && s.onAudio != nil {
+			s.onAudio(frame)
+		}
+		s.mu.Lock()
+		if device != ntgcalls.MicrophoneStream {
+			s.stats.VideoFrames++
+		} else {
+			s.stats.AudioFrames++
+		}
+		s.mu.Unlock()
+		next = next.Add(interval)
+	}
+}
+
+// position returns the track position in seconds the stream has reached, which is where a
+// restarted ffmpeg process resumes. Live streams always restart at the live edge.
+func (s *externalStream) position() int {
+	if s.source.live {
+		return 0
+	}
+	s.mu.Lock()
+	played := time.Duration(s.stats.AudioFrames) * audioFrameDuration
+	s.mu.Unlock()
+	return s.source.seek + int(played.Seconds()*s.source.tempo)
+}
+
+// played returns how many seconds of audio have been sent to the call.
+func (s *externalStream) played() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return uint64((time.Duration(s.stats.AudioFrames) * audioFrameDuration).Seconds())
+}
+
+func (s *externalStream) snapshot() streamStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// setPaused pauses or resumes sending frames. ffmpeg is left running and blocks on its
+// full output pipe while the stream is paused.
+func (s *externalStream) setPaused(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if paused == s.paused {
+		return
+	}
+	s.paused = paused
+	if paused {
+		s.resumed = make(chan struct{})
+	} else {
+		close(s.resumed)
+	}
+}
+
+// isPaused reports whether the stream is paused.
+func (s *externalStream) isPaused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+// setMuted records whether the call is muted.
+func (s *externalStream) setMuted(muted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.muted = muted
+}
+
+// deferRestart marks the stream for a restart if it is paused or muted, and reports whether it did.
+func (s *externalStream) deferRestart() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paused || s.muted {
+		s.restartPending = true
+	}
+	return s.restartPending
+}
+
+// takePendingRestart reports whether the stream is audible again with a restart pending, and
+// clears the pending restart if so.
+func (s *externalStream) takePendingRestart() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.restartPending || s.paused || s.muted {
+		return false
+	}
+	s.restartPending = false
+	return true
+}
+
+// waitResumed blocks while the stream is paused. It reports whether it had to wait.
+func (s *externalStream) waitResumed() bool {
+	s.mu.Lock()
+	paused, resumed := s.paused, s.resumed
+	s.mu.Unlock()
+	if !paused {
+		return false
+	}
+	select {
+	case <-resumed:
+	case <-s.ctx.Done():
+	}
+	return true
+}
@@ -33,6 +33,47 @@ type TelegramCalls struct {
 
 	leavingMu sync.Mutex
 	leaving   map[int]bool
+
+	persistMu sync.Mutex
+	// persisting is set once queue persistence has started; until then nothing is saved.
+	persisting  bool
+	dirtyQueues map[int64]struct{}
+	savePending bool
+	// saveMu serializes queue saves, so an older snapshot never overwrites a newer one.
+	saveMu sync.Mutex
+
+	streamsMu sync.Mutex
+	streams   map[int64]streamState
+
+	prefetchMu sync.Mutex
+	prefetches map[int64]map[string]*prefetchTask
+
+	externalMu sync.Mutex
+	externals  map[int64]*externalStream
+
+	ingestMu    sync.Mutex
+	ingestPorts map[int64]int
+
+	recordMu   sync.Mutex
+	recordings map[int64]*recording
+
+	captureMu sync.Mutex
+	captures  map[int64]int
+
+	overlayMu sync.Mutex
+	overlays  map[int64]*pcmMixer
+
+	bridgeMu sync.Mutex
+	bridges  map[int64]*bridge
+
+	clipMu sync.Mutex
+	clips  map[int64]int
+
+	alwaysOnMu sync.Mutex
+	refills    map[int64]*alwaysOnState
+
+	cardMu sync.Mutex
+	cards  map[int64]*nowPlayingCard
 }
 
 var (
@@ -49,6 +90,18 @@ func getCalls() *TelegramCalls {
 			statusCache: cache.NewCache[td.ChatMemberStatus](2 * time.Hour),
 			inviteCache: cache.NewCache[string](2 * time.Hour),
 			leaving:     make(map[int]bool),
+			dirtyQueues: make(map[int64]struct{}),
+			streams:     make(map[int64]streamState),
+			prefetches:  make(map[int64]map[string]*prefetchTask),
+			externals:   make(map[int64]*externalStream),
+			ingestPorts: make(map[int64]int),
+			recordings:  make(map[int64]*recording),
+			captures:    make(map[int64]int),
+			overlays:    make(map[int64]*pcmMixer),
+			bridges:     make(map[int64]*bridge),
+			clips:       make(map[int64]int),
+			refills:     make(map[int64]*alwaysOnState),
+			cards:       make(map[int64]*nowPlayingCard),
 		}
 	})
 	return instance
@@ -310,6 +310,8 @@ func (c *TelegramCalls) StartClient(apiID int32, apiHash, stringSession string)
 }
 
 func (c *TelegramCalls) StopAllClients() {
+	c.saveQueues(true)
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 